@@ -0,0 +1,30 @@
+package twitch
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+const (
+	anonymousLoginPrefix string = "justinfan"
+)
+
+type AnonymousAuthProvider struct {
+	login string
+}
+
+func (a *AnonymousAuthProvider) GetAccessToken() (string, error) {
+	return "", nil
+}
+
+func (a *AnonymousAuthProvider) GetLoginAndAccessToken() (string, string, error) {
+	return a.login, "", nil
+}
+
+func NewAnonymousProvider() *AnonymousAuthProvider {
+	// Twitch allows any justinfan login to connect without a token
+	provider := &AnonymousAuthProvider{
+		login: fmt.Sprintf("%s%d", anonymousLoginPrefix, rand.IntN(89999)+10000),
+	}
+	return provider
+}
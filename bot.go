@@ -1,14 +1,37 @@
 package twitch
 
 import (
+	"context"
 	"log"
+	"reflect"
 	"strings"
+	"sync"
 )
 
 type TwitchBot struct {
-	chat              *ChatClient
-	chatCommandPrefix string
-	chatCommands      map[string][]ChatCommander
+	cancel                   context.CancelFunc
+	cancelMutex              sync.Mutex
+	chat                     *ChatClient
+	chatCommandPrefix        string
+	chatCommands             map[string][]ChatCommander
+	commandMiddleware        []CommandMiddleware
+	commandOwners            []string
+	commandsMutex            sync.RWMutex
+	permissionDeniedResponse string
+	whisperCommands          bool
+	whisperSender            WhisperSender
+}
+
+func (b *TwitchBot) ChatChannelState(channel string) (ChatChannelState, bool) {
+	return b.chat.ChannelState(channel)
+}
+
+func (b *TwitchBot) ChatChannels() []string {
+	return b.chat.Channels()
+}
+
+func (b *TwitchBot) ChatEvents(ctx context.Context, filter ChatEventFilter, options ...ChatEventStreamOption) <-chan Event {
+	return b.chat.Events(ctx, filter, options...)
 }
 
 func (b *TwitchBot) ChatJoin(channel string) error {
@@ -19,15 +42,131 @@ func (b *TwitchBot) ChatJoin(channel string) error {
 	return nil
 }
 
-func (b *TwitchBot) ChatReply(message *ChatPrivateMessage, response string) {
-	b.chat.Reply(message, response)
+func (b *TwitchBot) ChatJoinMany(channels ...string) error {
+	return b.chat.JoinMany(channels...)
+}
+
+func (b *TwitchBot) ChatPart(channel string) error {
+	return b.chat.Part(channel)
+}
+
+func (b *TwitchBot) ChatReply(message *ChatPrivateMessage, response string) error {
+	return b.chat.Reply(message, response)
+}
+
+func (b *TwitchBot) ChatReplyWithResult(ctx context.Context, message *ChatPrivateMessage, response string) (*ChatSendResult, error) {
+	return b.chat.ReplyWithResult(ctx, message, response)
+}
+
+func (b *TwitchBot) ChatSay(channel string, message string) error {
+	return b.chat.Say(channel, message)
+}
+
+func (b *TwitchBot) ChatSayWithResult(ctx context.Context, channel string, message string) (*ChatSendResult, error) {
+	return b.chat.SayWithResult(ctx, channel, message)
+}
+
+func (b *TwitchBot) ChatSelf() ChatSelfState {
+	return b.chat.Self()
+}
+
+func (b *TwitchBot) dispatchChatCommand(ctx context.Context, message *ChatPrivateMessage, whisper *ChatWhisperMessage) {
+	// Check to see if a command has requested
+	if strings.HasPrefix(message.Message, b.chatCommandPrefix) && len(message.Message) > 1 {
+		messageParts := strings.Split(message.Message, " ")
+		commandName := strings.TrimPrefix(messageParts[0], b.chatCommandPrefix)
+		// Check if handler(s) have been loaded for the command
+		b.commandsMutex.RLock()
+		handlers, ok := b.chatCommands[commandName]
+		b.commandsMutex.RUnlock()
+		if ok {
+			commandParams := messageParts[1:]
+			// Ensure there is at least 1 command handler
+			if len(handlers) > 0 {
+				// Build command context
+				commandContext := &ChatCommandContext{}
+				commandContext.CommandName = commandName
+				commandContext.Context = ctx
+				if len(messageParts) > 1 {
+					commandContext.CommandParams = commandParams
+				}
+				commandContext.Message = message
+				commandContext.Reply = b.ChatReply
+				commandContext.Say = b.ChatSay
+				// Commands sent by whisper are answered by whisper
+				if whisper != nil {
+					commandContext.Reply = func(message *ChatPrivateMessage, response string) error {
+						err := b.Whisper(whisper.UserId, response)
+						if err != nil {
+							log.Printf("Unable to whisper %s: %s", whisper.Login, err)
+						}
+						return err
+					}
+					commandContext.Whisper = whisper
+				}
+				// Permissions are checked before the middleware so that a command
+				// the user can't run doesn't count towards things like cooldowns
+				if !b.allowChatCommand(commandContext, handlers) {
+					return
+				}
+				// Run the command through the middleware
+				b.commandHandler()(commandContext)
+			}
+		}
+	}
+}
+
+func (b *TwitchBot) EnableWhisperCommands() {
+	// Only add the handler once
+	if b.whisperCommands {
+		return
+	}
+	b.whisperCommands = true
+	b.chat.OnWhisperContext(b.handleWhisperCommand)
 }
 
-func (b *TwitchBot) ChatSay(channel string, message string) {
-	b.chat.Say(channel, message)
+func (b *TwitchBot) handleChatCommand(ctx context.Context, message *ChatPrivateMessage) {
+	b.dispatchChatCommand(ctx, message, nil)
+}
+
+func (b *TwitchBot) handleWhisperCommand(ctx context.Context, whisper *ChatWhisperMessage) {
+	// Treat the whisper as a message so commands can handle both the same way
+	message := &ChatPrivateMessage{
+		Badges:      whisper.Badges,
+		Color:       whisper.Tags["color"],
+		DisplayName: whisper.DisplayName,
+		Id:          whisper.MessageId,
+		Message:     whisper.Message,
+		Tags:        whisper.Tags,
+		UserId:      whisper.UserId,
+		Username:    whisper.Login,
+	}
+	b.dispatchChatCommand(ctx, message, whisper)
+}
+
+func (b *TwitchBot) OnChatAnnouncement(handler func(message *ChatAnnouncementMessage)) {
+	b.chat.OnAnnouncement(handler)
+}
+
+func (b *TwitchBot) OnChatAny(handler func(event Event)) func() {
+	return b.chat.OnAny(handler)
+}
+
+func (b *TwitchBot) OnChatBitsBadgeTier(handler func(message *ChatBitsBadgeTierMessage)) {
+	b.chat.OnBitsBadgeTier(handler)
+}
+
+func (b *TwitchBot) OnChatClearChat(handler func(message *ChatClearChatMessage)) {
+	b.chat.OnClearChat(handler)
+}
+
+func (b *TwitchBot) OnChatClearMessage(handler func(message *ChatClearMessageMessage)) {
+	b.chat.OnClearMessage(handler)
 }
 
 func (b *TwitchBot) OnChatCommand(commandName string, command ChatCommander) {
+	b.commandsMutex.Lock()
+	defer b.commandsMutex.Unlock()
 	b.chatCommands[commandName] = append(b.chatCommands[commandName], command)
 }
 
@@ -36,10 +175,30 @@ func (b *TwitchBot) OnChatConnect(handler func(message *ChatConnectMessage)) err
 	return nil
 }
 
+func (b *TwitchBot) OnChatDisconnect(handler func(message *ChatDisconnectMessage)) {
+	b.chat.OnDisconnect(handler)
+}
+
+func (b *TwitchBot) OnChatError(handler func(err error, raw string)) {
+	b.chat.OnError(handler)
+}
+
+func (b *TwitchBot) OnChatGlobalUserState(handler func(message *ChatGlobalUserStateMessage)) {
+	b.chat.OnGlobalUserState(handler)
+}
+
 func (b *TwitchBot) OnChatJoin(handler func(message *ChatJoinMessage)) {
 	b.chat.OnJoin(handler)
 }
 
+func (b *TwitchBot) OnChatJoinFailed(handler func(message *ChatJoinFailedMessage)) {
+	b.chat.OnJoinFailed(handler)
+}
+
+func (b *TwitchBot) OnChatNotice(handler func(message *ChatNoticeMessage)) {
+	b.chat.OnNotice(handler)
+}
+
 func (b *TwitchBot) OnChatPart(handler func(message *ChatPartMessage)) {
 	b.chat.OnPart(handler)
 }
@@ -57,43 +216,96 @@ func (b *TwitchBot) OnChatPrivateMessage(handler func(message *ChatPrivateMessag
 	return nil
 }
 
-func (b *TwitchBot) Start() {
-	// Create command handler
-	b.chat.OnPrivateMessage(func(message *ChatPrivateMessage) {
-		// Check to see if a command has requested
-		if strings.HasPrefix(message.Message, b.chatCommandPrefix) && len(message.Message) > 1 {
-			messageParts := strings.Split(message.Message, " ")
-			commandName := strings.TrimPrefix(messageParts[0], b.chatCommandPrefix)
-			// Check if handler(s) have been loaded for the command
-			handlers, ok := b.chatCommands[commandName]
-			if ok {
-				commandParams := messageParts[1:]
-				// Ensure there is at least 1 command handler
-				if len(handlers) > 0 {
-					// Build command context
-					commandContext := &ChatCommandContext{}
-					commandContext.CommandName = commandName
-					if len(messageParts) > 1 {
-						commandContext.CommandParams = commandParams
-					}
-					commandContext.Message = message
-					commandContext.Reply = b.ChatReply
-					commandContext.Say = b.ChatSay
-					// Call each handler
-					for _, handler := range handlers {
-						handler.Execute(commandContext)
-					}
-				}
-			}
-		}
-	})
+func (b *TwitchBot) OnChatQueueOverflow(handler func(message *ChatQueueOverflowMessage)) {
+	b.chat.OnQueueOverflow(handler)
+}
+
+func (b *TwitchBot) OnChatRaid(handler func(message *ChatRaidMessage)) {
+	b.chat.OnRaid(handler)
+}
+
+func (b *TwitchBot) OnChatRaw(handler func(message *IrcMessage)) func() {
+	return b.chat.OnRaw(handler)
+}
+
+func (b *TwitchBot) OnChatReconnect(handler func(message *ChatReconnectMessage)) {
+	b.chat.OnReconnect(handler)
+}
+
+func (b *TwitchBot) OnChatRitual(handler func(message *ChatRitualMessage)) {
+	b.chat.OnRitual(handler)
+}
+
+func (b *TwitchBot) OnChatRoomState(handler func(message *ChatRoomStateMessage)) {
+	b.chat.OnRoomState(handler)
+}
+
+func (b *TwitchBot) OnChatSub(handler func(message *ChatSubMessage)) {
+	b.chat.OnSub(handler)
+}
+
+func (b *TwitchBot) OnChatSubGift(handler func(message *ChatSubGiftMessage)) {
+	b.chat.OnSubGift(handler)
+}
+
+func (b *TwitchBot) OnChatSubMysteryGift(handler func(message *ChatSubMysteryGiftMessage)) {
+	b.chat.OnSubMysteryGift(handler)
+}
+
+func (b *TwitchBot) OnChatUserNotice(handler func(message *ChatUserNoticeMessage)) {
+	b.chat.OnUserNotice(handler)
+}
+
+func (b *TwitchBot) OnChatUserState(handler func(message *ChatUserStateMessage)) {
+	b.chat.OnUserState(handler)
+}
+
+func (b *TwitchBot) OnChatWhisper(handler func(message *ChatWhisperMessage)) {
+	b.chat.OnWhisper(handler)
+}
+
+func (b *TwitchBot) Run(ctx context.Context) error {
+	// Keep hold of the cancel func so the bot can be stopped
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	b.cancelMutex.Lock()
+	b.cancel = cancel
+	b.cancelMutex.Unlock()
 	log.Println("Starting bot...")
-	b.chat.Start()
+	return b.chat.Run(ctx)
+}
+
+func (b *TwitchBot) Start() {
+	b.Run(context.Background())
 }
 
-func NewBot(authProvider AuthProvider) (*TwitchBot, error) {
+func (b *TwitchBot) Stop() {
+	b.cancelMutex.Lock()
+	defer b.cancelMutex.Unlock()
+	if b.cancel != nil {
+		b.cancel()
+	}
+}
+
+func (b *TwitchBot) subscribe(eventType reflect.Type, handler func(ctx context.Context, event Event)) func() {
+	return b.chat.subscribe(eventType, handler)
+}
+
+func (b *TwitchBot) Whisper(toUserId string, message string) error {
+	if b.chat.IsReadOnly() {
+		return ErrReadOnly
+	}
+	return b.whisperSender.SendWhisper(toUserId, message)
+}
+
+func NewBot(authProvider AuthProvider, chatOptions ...ChatClientOption) (*TwitchBot, error) {
 	// Create chat client
-	chat, err := NewChatClient(authProvider)
+	chat, err := NewChatClient(authProvider, chatOptions...)
+	if err != nil {
+		return nil, err
+	}
+	// Create whisper sender
+	whisperSender, err := NewHelixWhisperSender(chat.authProvider)
 	if err != nil {
 		return nil, err
 	}
@@ -102,6 +314,9 @@ func NewBot(authProvider AuthProvider) (*TwitchBot, error) {
 		chat:              chat,
 		chatCommands:      make(map[string][]ChatCommander),
 		chatCommandPrefix: "!",
+		whisperSender:     whisperSender,
 	}
+	// Create command handler
+	bot.chat.OnPrivateMessageContext(bot.handleChatCommand)
 	return bot, nil
 }
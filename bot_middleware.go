@@ -0,0 +1,35 @@
+package twitch
+
+type CommandHandler func(commandContext *ChatCommandContext)
+
+type CommandMiddleware func(next CommandHandler) CommandHandler
+
+func (b *TwitchBot) commandHandler() CommandHandler {
+	b.commandsMutex.RLock()
+	defer b.commandsMutex.RUnlock()
+	// Wrap from the inside out so the first middleware added runs first
+	handler := CommandHandler(b.executeChatCommand)
+	for index := len(b.commandMiddleware) - 1; index >= 0; index-- {
+		handler = b.commandMiddleware[index](handler)
+	}
+	return handler
+}
+
+func (b *TwitchBot) executeChatCommand(commandContext *ChatCommandContext) {
+	// The command is looked up again as middleware may have changed it
+	b.commandsMutex.RLock()
+	handlers := b.chatCommands[commandContext.CommandName]
+	b.commandsMutex.RUnlock()
+	// Call each handler the user is allowed to run
+	for _, handler := range handlers {
+		if isChatCommandAllowed(handler, commandContext.Permission) {
+			handler.Execute(commandContext)
+		}
+	}
+}
+
+func (b *TwitchBot) UseCommandMiddleware(middleware ...CommandMiddleware) {
+	b.commandsMutex.Lock()
+	defer b.commandsMutex.Unlock()
+	b.commandMiddleware = append(b.commandMiddleware, middleware...)
+}
@@ -0,0 +1,86 @@
+package twitch
+
+import (
+	"slices"
+)
+
+const (
+	ChatPermissionEveryone ChatPermission = iota
+	ChatPermissionSubscriber
+	ChatPermissionVip
+	ChatPermissionModerator
+	ChatPermissionBroadcaster
+	ChatPermissionOwner
+)
+
+type chatPermissionCommand struct {
+	ChatCommander
+	permission ChatPermission
+}
+
+func (c *chatPermissionCommand) Permission() ChatPermission {
+	return c.permission
+}
+
+func (b *TwitchBot) allowChatCommand(commandContext *ChatCommandContext, handlers []ChatCommander) bool {
+	commandContext.Permission = b.chatPermission(commandContext.Message)
+	if slices.ContainsFunc(handlers, func(handler ChatCommander) bool {
+		return isChatCommandAllowed(handler, commandContext.Permission)
+	}) {
+		return true
+	}
+	b.commandsMutex.RLock()
+	permissionDeniedResponse := b.permissionDeniedResponse
+	b.commandsMutex.RUnlock()
+	if permissionDeniedResponse != "" {
+		commandContext.Reply(commandContext.Message, permissionDeniedResponse)
+	}
+	return false
+}
+
+func (b *TwitchBot) chatPermission(message *ChatPrivateMessage) ChatPermission {
+	b.commandsMutex.RLock()
+	isOwner := message.UserId != "" && slices.Contains(b.commandOwners, message.UserId)
+	b.commandsMutex.RUnlock()
+	// The broadcaster is the user the room belongs to, whatever badge they
+	// have chosen to show
+	switch {
+	case isOwner:
+		return ChatPermissionOwner
+	case message.UserId != "" && message.UserId == message.RoomId, message.IsBroadcaster():
+		return ChatPermissionBroadcaster
+	case message.IsModerator():
+		return ChatPermissionModerator
+	case message.IsVip():
+		return ChatPermissionVip
+	case message.IsSubscriber():
+		return ChatPermissionSubscriber
+	default:
+		return ChatPermissionEveryone
+	}
+}
+
+func isChatCommandAllowed(handler ChatCommander, permission ChatPermission) bool {
+	permissionedHandler, ok := handler.(ChatPermissionedCommander)
+	return !ok || permission >= permissionedHandler.Permission()
+}
+
+func (b *TwitchBot) OnChatCommandWithPermission(commandName string, permission ChatPermission, command ChatCommander) {
+	b.OnChatCommand(commandName, &chatPermissionCommand{
+		ChatCommander: command,
+		permission:    permission,
+	})
+}
+
+func (b *TwitchBot) SetChatCommandOwners(userIds ...string) {
+	b.commandsMutex.Lock()
+	defer b.commandsMutex.Unlock()
+	b.commandOwners = userIds
+}
+
+func (b *TwitchBot) SetChatPermissionDeniedResponse(response string) {
+	// A blank response ignores the command without saying anything
+	b.commandsMutex.Lock()
+	defer b.commandsMutex.Unlock()
+	b.permissionDeniedResponse = response
+}
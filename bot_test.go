@@ -0,0 +1,144 @@
+package twitch_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ynotnauk/go-twitch"
+	"github.com/ynotnauk/go-twitch/twitchtest"
+)
+
+type testChatCommand struct {
+	response string
+}
+
+func (c *testChatCommand) Execute(context *twitch.ChatCommandContext) {
+	context.Reply(context.Message, c.response)
+}
+
+type testChatCommandFunc func(context *twitch.ChatCommandContext)
+
+func (c testChatCommandFunc) Execute(context *twitch.ChatCommandContext) {
+	c(context)
+}
+
+func startTestBot(t *testing.T, server *twitchtest.Server, setup func(bot *twitch.TwitchBot), options ...twitch.ChatClientOption) *twitch.TwitchBot {
+	t.Helper()
+	bot, err := twitch.NewBot(twitchtest.NewAuthProvider("testbot"), append(server.ClientOptions(), options...)...)
+	if err != nil {
+		t.Fatalf("unable to create bot: %s", err)
+	}
+	setup(bot)
+	// Wait for the welcome to be handled so the bot is ready to use
+	connected := make(chan *twitch.ChatConnectMessage, 1)
+	bot.OnChatConnect(func(message *twitch.ChatConnectMessage) {
+		sendEvent(connected, message)
+	})
+	runTestClient(t, bot.Run)
+	waitForEvent(t, connected)
+	// Wait for the echo as well so the bot knows it is in the channel
+	joined := make(chan *twitch.ChatJoinMessage, 1)
+	unsubscribe := twitch.On(bot, func(message *twitch.ChatJoinMessage) {
+		sendEvent(joined, message)
+	})
+	defer unsubscribe()
+	err = bot.ChatJoin("channel")
+	if err != nil {
+		t.Fatalf("unable to join: %s", err)
+	}
+	waitForLine(t, server, "JOIN #channel")
+	waitForEvent(t, joined)
+	return bot
+}
+
+func TestBotCommandReply(t *testing.T) {
+	for name, newServer := range testServers {
+		t.Run(name, func(t *testing.T) {
+			server := startTestServer(t, newServer)
+			startTestBot(t, server, func(bot *twitch.TwitchBot) {
+				bot.OnChatCommand("ping", &testChatCommand{
+					response: "pong!",
+				})
+			})
+			// Messages that aren't commands are ignored
+			server.Send("@id=message-1;user-id=100 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :ping")
+			server.Send("@id=message-2;user-id=100 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :!ping")
+			line := waitForLine(t, server, "@reply-parent-msg-id=")
+			expected := "@reply-parent-msg-id=message-2 PRIVMSG #channel pong!"
+			if line != expected {
+				t.Errorf("replied with %q, want %q", line, expected)
+			}
+		})
+	}
+}
+
+func TestBotCommandSayWithResult(t *testing.T) {
+	dispatchOptions := map[string][]twitch.ChatClientOption{
+		"sync":        nil,
+		"worker pool": {twitch.WithWorkerPoolDispatch(1)},
+	}
+	for name, options := range dispatchOptions {
+		t.Run(name, func(t *testing.T) {
+			server := startTestServer(t, twitchtest.NewServer)
+			results := make(chan error, 1)
+			startTestBot(t, server, func(bot *twitch.TwitchBot) {
+				// The answer to the message has to be handled while the command
+				// is still waiting for it
+				bot.OnChatCommand("giveaway", testChatCommandFunc(func(context *twitch.ChatCommandContext) {
+					result, err := bot.ChatSayWithResult(context.Context, context.Message.Channel, "giveaway started")
+					if err == nil && result.Id == "" {
+						err = errors.New("result has no id")
+					}
+					results <- err
+				}))
+			}, options...)
+			server.Send("@id=message-1;user-id=100 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :!giveaway")
+			waitForLine(t, server, "PRIVMSG #channel :giveaway started")
+			err := waitForEvent(t, results)
+			if err != nil {
+				t.Errorf("unable to say: %s", err)
+			}
+		})
+	}
+}
+
+func TestBotPermissionCheckedBeforeMiddleware(t *testing.T) {
+	server := startTestServer(t, twitchtest.NewServer)
+	middlewarePermissions := make(chan twitch.ChatPermission, 4)
+	startTestBot(t, server, func(bot *twitch.TwitchBot) {
+		bot.SetChatPermissionDeniedResponse("not allowed")
+		// Stands in for something like a cooldown that must only see commands
+		// the user is allowed to run
+		bot.UseCommandMiddleware(func(next twitch.CommandHandler) twitch.CommandHandler {
+			return func(context *twitch.ChatCommandContext) {
+				middlewarePermissions <- context.Permission
+				next(context)
+			}
+		})
+		bot.OnChatCommandWithPermission("settitle", twitch.ChatPermissionModerator, &testChatCommand{
+			response: "title set",
+		})
+	})
+	server.Send("@id=message-1;user-id=100;room-id=1 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :!settitle hello")
+	line := waitForLine(t, server, "@reply-parent-msg-id=message-1")
+	expected := "@reply-parent-msg-id=message-1 PRIVMSG #channel :not allowed"
+	if line != expected {
+		t.Errorf("replied with %q, want %q", line, expected)
+	}
+	server.Send("@badges=moderator/1;id=message-2;mod=1;user-id=200;room-id=1 :moderator!moderator@moderator.tmi.twitch.tv PRIVMSG #channel :!settitle hello")
+	line = waitForLine(t, server, "@reply-parent-msg-id=message-2")
+	expected = "@reply-parent-msg-id=message-2 PRIVMSG #channel :title set"
+	if line != expected {
+		t.Errorf("replied with %q, want %q", line, expected)
+	}
+	// Only the moderator's command made it to the middleware
+	permission := waitForEvent(t, middlewarePermissions)
+	if permission != twitch.ChatPermissionModerator {
+		t.Errorf("middleware saw %v, want %v", permission, twitch.ChatPermissionModerator)
+	}
+	select {
+	case permission := <-middlewarePermissions:
+		t.Errorf("middleware ran again with %v", permission)
+	default:
+	}
+}
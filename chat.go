@@ -2,14 +2,15 @@ package twitch
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
 	"errors"
-	"fmt"
 	"io"
 	"log"
+	"math/rand/v2"
 	"net"
 	"net/textproto"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,149 +18,299 @@ import (
 )
 
 const (
-	serverAddress    string        = "irc.chat.twitch.tv:6697"
-	idlePingInterval time.Duration = time.Second * 15
-	pingTimeout      time.Duration = time.Second * 5
+	serverAddress      string        = "irc.chat.twitch.tv:6697"
+	idlePingInterval   time.Duration = time.Second * 15
+	pingTimeout        time.Duration = time.Second * 5
+	reconnectBaseDelay time.Duration = time.Second * 1
+	reconnectMaxDelay  time.Duration = time.Minute * 2
+	shutdownTimeout    time.Duration = time.Second * 5
 )
 
 var (
-	ErrBlankChannel       error = errors.New("channel cannot be blank")
-	ErrBlankRawIrcMessage error = errors.New("rawIrcMessage cannot be blank")
+	chatMessageSanitizer *strings.Replacer = strings.NewReplacer(
+		"\r\n", " ",
+		"\r", " ",
+		"\n", " ",
+		"\x00", "",
+	)
+)
+
+var (
+	ErrReadOnly           error = errors.New("chat client is read only")
+	ErrReconnectRequested error = errors.New("server requested a reconnect")
 )
 
 type ChatClient struct {
+	address                    string
 	authProvider               AuthProvider
-	connectionOutgoingChannel  chan string
+	channels                   map[string]bool
+	channelsMutex              sync.Mutex
+	connected                  bool
+	connection                 ChatTransport
 	connectionIncommingChannel chan string
+	deliveries                 map[string][]*chatDelivery
+	deliveriesMutex            sync.Mutex
+	dialer                     ContextDialer
+	duplicateBypass            bool
 	disconnectChannel          chan bool
+	dispatcher                 *chatDispatcher
+	disconnectError            error
+	disconnectedAt             time.Time
+	eventStreams               map[*chatEventStream]bool
+	eventStreamsMutex          sync.Mutex
+	events                     *chatEventBus
+	joinedChannels             map[string]bool
 	keepAliveReset             chan bool
-	onConnect                  []func(message *ChatConnectMessage)
-	onJoin                     []func(message *ChatJoinMessage)
-	onPart                     []func(message *ChatPartMessage)
-	onPing                     []func(message *ChatPingMessage)
-	onPong                     []func(message *ChatPongMessage)
-	onPrivateMessage           []func(message *ChatPrivateMessage)
+	lastMessages               map[string]string
+	lastMessagesMutex          sync.Mutex
+	messageSplitting           bool
+	maxQueueSize               int
+	outgoingQueue              *chatOutgoingQueue
+	pendingJoins               map[string]*time.Timer
+	plaintext                  bool
 	pongReceived               chan bool
+	rateLimiter                *chatRateLimiter
+	readOnly                   bool
+	rateLimits                 ChatRateLimits
+	reconnectAttempts          int
+	reconnectRequested         bool
+	shutdownChannel            chan bool
+	state                      *chatStateStore
+	tlsConfig                  *tls.Config
+	websocket                  bool
 }
 
-func (c *ChatClient) connect() error {
-	log.Printf("Attempting to connect to Twitch [%s]", serverAddress)
-
-	// Create a dialer
-	netDialer := &net.Dialer{
-		KeepAlive: time.Second * 10,
+func (c *ChatClient) connect(ctx context.Context) error {
+	// Get login details from auth provider, this is done for every connection
+	// so that a token refreshed while we were disconnected is picked up
+	login, accessToken, err := c.authProvider.GetLoginAndAccessToken()
+	if err != nil {
+		return err
 	}
 
-	// tls configuration
-	tlsConfig := &tls.Config{
-		MinVersion: tls.VersionTLS12,
-	}
+	log.Printf("Attempting to connect to Twitch [%s]", c.address)
 
 	// Attempt to connect to server
-	connection, err := tls.DialWithDialer(netDialer, "tcp", serverAddress, tlsConfig)
+	connection, err := c.dialer.DialContext(ctx, "tcp", c.address)
+	if err != nil {
+		return err
+	}
+
+	host, _, err := net.SplitHostPort(c.address)
 	if err != nil {
+		connection.Close()
 		return err
 	}
+
+	// Wrap the connection in tls unless we have been told not to
+	if !c.plaintext {
+		tlsConfig := c.tlsConfig.Clone()
+		// The server name is needed to verify the certificate
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = host
+		}
+		tlsConnection := tls.Client(connection, tlsConfig)
+		err = tlsConnection.HandshakeContext(ctx)
+		if err != nil {
+			connection.Close()
+			return err
+		}
+		connection = tlsConnection
+	}
+
+	// IRC can either be spoken directly or carried over a websocket
+	var transport ChatTransport = connection
+	if c.websocket {
+		transport, err = newWebSocketConnection(ctx, connection, c.address)
+		if err != nil {
+			connection.Close()
+			return err
+		}
+	}
 	log.Println("Connected to Twitch!")
 
+	// Reset the per connection state
+	c.connected = false
+	c.connection = transport
+	c.disconnectChannel = make(chan bool)
+	c.disconnectError = nil
+	c.shutdownChannel = make(chan bool)
+	c.drainChannels()
+	c.resetChannels()
+	c.state.clear()
+	c.state.updateSelf(func(selfState *ChatSelfState) {
+		// Twitch always sends logins in lowercase so store it the same way to
+		// be able to spot our own messages
+		selfState.Login = strings.ToLower(login)
+	})
+
 	// Start all required go routines
 	wg := &sync.WaitGroup{}
-	wg.Add(4)
+	wg.Add(5)
 	c.startMessageParser(wg)
-	c.startConnectionReader(wg, connection)
-	c.startConnectionWriter(wg, connection)
-	c.startKeepAlive(wg, connection)
-
-	// Get login details from auth provider
-	login, accessToken, err := c.authProvider.GetLoginAndAccessToken()
-	if err != nil {
-		return err
-	}
+	c.startConnectionReader(wg, transport)
+	c.startConnectionWriter(wg, transport)
+	c.startKeepAlive(wg, transport)
+	c.startShutdownWatcher(ctx, wg)
 
 	// Setup the connection
-	c.send("CAP REQ :twitch.tv/commands twitch.tv/membership twitch.tv/tags")
-	c.send("PASS oauth:" + accessToken)
-	c.send("NICK " + login)
+	c.send(&IrcMessage{
+		Command: "CAP",
+		Params:  []string{"REQ", "twitch.tv/commands twitch.tv/membership twitch.tv/tags"},
+	})
+	// Anonymous connections don't send a password
+	if !c.readOnly {
+		c.send(&IrcMessage{
+			Command: "PASS",
+			Params:  []string{"oauth:" + accessToken},
+		})
+	}
+	c.send(&IrcMessage{
+		Command: "NICK",
+		Params:  []string{login},
+	})
 
 	// Wait for all go routines to close
 	wg.Wait()
 	log.Println("Disconnected from Twitch")
-	return err
+	c.failDeliveries(ErrDeliveryDisconnected)
+	if c.reconnectRequested {
+		return ErrReconnectRequested
+	}
+	return c.disconnectError
+}
+
+func (c *ChatClient) drainChannels() {
+	// Anything left over belongs to the previous connection and must not be
+	// sent before the new connection has been authenticated
+	c.outgoingQueue.clear()
+	for {
+		select {
+		case <-c.connectionIncommingChannel:
+		case <-c.keepAliveReset:
+		case <-c.pongReceived:
+		default:
+			return
+		}
+	}
 }
 
 func (c *ChatClient) handleParsedIrcMessage(parsedIrcMessage *IrcMessage) error {
 	switch parsedIrcMessage.Command {
 	case "001":
+		c.connected = true
+		// Rejoin any channels we were in before the connection dropped
+		c.channelsMutex.Lock()
+		channels := make([]string, 0, len(c.channels))
+		for channel := range c.channels {
+			channels = append(channels, channel)
+		}
+		c.channelsMutex.Unlock()
+		slices.Sort(channels)
+		c.sendJoins(channels)
+		// Let the reconnect handlers know we are back
+		if !c.disconnectedAt.IsZero() {
+			reconnectMessage := &ChatReconnectMessage{
+				Attempts: c.reconnectAttempts,
+				Downtime: time.Since(c.disconnectedAt),
+			}
+			c.disconnectedAt = time.Time{}
+			c.emit(parsedIrcMessage, reconnectMessage)
+		}
+		c.reconnectAttempts = 0
 		// Run handlers if loaded
-		if len(c.onConnect) > 0 {
+		if hasSubscribers[*ChatConnectMessage](c) {
 			connectMessage := &ChatConnectMessage{
-				Hostname: serverAddress,
-			}
-			for _, handler := range c.onConnect {
-				handler(connectMessage)
+				Hostname: c.address,
 			}
+			c.emit(parsedIrcMessage, connectMessage)
 		}
+	case "CLEARCHAT":
+		c.handleClearChat(parsedIrcMessage)
+	case "CLEARMSG":
+		c.handleClearMessage(parsedIrcMessage)
+	case "GLOBALUSERSTATE":
+		c.handleGlobalUserState(parsedIrcMessage)
 	case "JOIN":
+		// Twitch echoes our own joins back to confirm them
+		if parsedIrcMessage.Source.Username == c.state.getSelf().Login {
+			c.confirmJoin(parsedIrcMessage.Params[0])
+		}
 		// Run handlers if loaded
-		if len(c.onJoin) > 0 {
+		if hasSubscribers[*ChatJoinMessage](c) {
 			joinMessage := &ChatJoinMessage{
 				Channel:  parsedIrcMessage.Params[0],
 				Username: parsedIrcMessage.Source.Username,
 			}
-			for _, handler := range c.onJoin {
-				handler(joinMessage)
-			}
+			c.emit(parsedIrcMessage, joinMessage)
 		}
+	case "NOTICE":
+		c.handleNotice(parsedIrcMessage)
 	case "PART":
+		// Forget the state of channels we have left
+		if parsedIrcMessage.Source.Username == c.state.getSelf().Login {
+			c.confirmPart(parsedIrcMessage.Params[0])
+			c.state.removeChannel(parsedIrcMessage.Params[0])
+			c.rateLimiter.setModerator(parsedIrcMessage.Params[0], false)
+		}
 		// Run handlers if loaded
-		if len(c.onPart) > 0 {
+		if hasSubscribers[*ChatPartMessage](c) {
 			partMessage := &ChatPartMessage{
 				Channel:  parsedIrcMessage.Params[0],
 				Username: parsedIrcMessage.Source.Username,
 			}
-			for _, handler := range c.onPart {
-				handler(partMessage)
-			}
+			c.emit(parsedIrcMessage, partMessage)
 		}
 	case "PING":
-		response := fmt.Sprintf("PONG :%s", parsedIrcMessage.Params[0])
-		c.send(response)
+		c.send(&IrcMessage{
+			Command: "PONG",
+			Params:  []string{parsedIrcMessage.Params[0]},
+		})
 		// Run handlers if loaded
-		if len(c.onPing) > 0 {
+		if hasSubscribers[*ChatPingMessage](c) {
 			pingMessage := &ChatPingMessage{}
-			for _, handler := range c.onPing {
-				handler(pingMessage)
-			}
+			c.emit(parsedIrcMessage, pingMessage)
 		}
 	case "PONG":
-		c.pongReceived <- true
+		// Don't block the parser if nobody is waiting for this pong
+		select {
+		case c.pongReceived <- true:
+		default:
+		}
 		// Run handlers if loaded
-		if len(c.onPong) > 0 {
-			parsedTimestamp, err := strconv.ParseInt(strings.TrimPrefix(parsedIrcMessage.Params[1], ":"), 10, 64)
-			if err != nil {
-				return err
-			}
+		if hasSubscribers[*ChatPongMessage](c) {
 			pongMessage := &ChatPongMessage{
-				Server:    parsedIrcMessage.Params[0],
-				Timestamp: parsedTimestamp,
+				Server: parsedIrcMessage.Params[0],
 			}
-			for _, handler := range c.onPong {
-				handler(pongMessage)
+			// Only pongs for our own pings carry a timestamp
+			if len(parsedIrcMessage.Params) > 1 {
+				parsedTimestamp, err := strconv.ParseInt(parsedIrcMessage.Params[1], 10, 64)
+				if err != nil {
+					return err
+				}
+				pongMessage.Timestamp = parsedTimestamp
 			}
+			c.emit(parsedIrcMessage, pongMessage)
 		}
 	case "PRIVMSG":
 		// Run handlers if loaded
-		if len(c.onPrivateMessage) > 0 {
-			privateMessage := &ChatPrivateMessage{
-				Channel:  parsedIrcMessage.Params[0],
-				Message:  strings.TrimPrefix(strings.Join(parsedIrcMessage.Params[1:], " "), ":"),
-				Tags:     parsedIrcMessage.Tags,
-				Username: parsedIrcMessage.Source.Username,
-			}
-			for _, handler := range c.onPrivateMessage {
-				handler(privateMessage)
-			}
+		if hasSubscribers[*ChatPrivateMessage](c) {
+			privateMessage := newChatPrivateMessage(parsedIrcMessage)
+			c.emit(parsedIrcMessage, privateMessage)
 		}
+	case "USERNOTICE":
+		c.handleUserNotice(parsedIrcMessage)
+	case "USERSTATE":
+		c.handleUserState(parsedIrcMessage)
+	case "ROOMSTATE":
+		c.handleRoomState(parsedIrcMessage)
+	case "WHISPER":
+		c.handleWhisper(parsedIrcMessage)
+	case "RECONNECT":
+		// Twitch is about to restart the server, cycle the connection
+		log.Println("Server requested a reconnect")
+		c.reconnectRequested = true
+		c.connection.Close()
 	default:
 		log.Print("Unhandled command!")
 		log.Print("================================================================================")
@@ -171,160 +322,176 @@ func (c *ChatClient) handleParsedIrcMessage(parsedIrcMessage *IrcMessage) error
 	return nil
 }
 
-func (c *ChatClient) Join(channel string) error {
-	// TODO: check if channel can be split by commas
-	// If it can we need to check each segment
-	if channel == "" {
-		return ErrBlankChannel
-	}
-	// If channel does not start with a # add it
-	if !strings.HasPrefix(channel, "#") {
-		channel = fmt.Sprintf("#%s", channel)
-	}
-	c.send(fmt.Sprintf("JOIN %s", channel))
-	return nil
+func (c *ChatClient) IsReadOnly() bool {
+	return c.readOnly
 }
 
 func (c *ChatClient) OnConnect(handler func(message *ChatConnectMessage)) {
-	c.onConnect = append(c.onConnect, handler)
+	On(c, handler)
+}
+
+func (c *ChatClient) OnDisconnect(handler func(message *ChatDisconnectMessage)) {
+	On(c, handler)
 }
 
 func (c *ChatClient) OnJoin(handler func(message *ChatJoinMessage)) {
-	c.onJoin = append(c.onJoin, handler)
+	On(c, handler)
 }
 
 func (c *ChatClient) OnPart(handler func(message *ChatPartMessage)) {
-	c.onPart = append(c.onPart, handler)
+	On(c, handler)
 }
 
 func (c *ChatClient) OnPing(handler func(message *ChatPingMessage)) {
-	c.onPing = append(c.onPing, handler)
+	On(c, handler)
 }
 
 func (c *ChatClient) OnPong(handler func(message *ChatPongMessage)) {
-	c.onPong = append(c.onPong, handler)
+	On(c, handler)
 }
 
 func (c *ChatClient) OnPrivateMessage(handler func(message *ChatPrivateMessage)) {
-	c.onPrivateMessage = append(c.onPrivateMessage, handler)
+	On(c, handler)
+}
+
+func (c *ChatClient) OnPrivateMessageContext(handler func(ctx context.Context, message *ChatPrivateMessage)) {
+	OnContext(c, handler)
+}
+
+func (c *ChatClient) OnQueueOverflow(handler func(message *ChatQueueOverflowMessage)) {
+	On(c, handler)
 }
 
-func (c *ChatClient) parseRawIrcMessage(rawIrcMessage string) (*IrcMessage, error) {
-	// Ensure rawIrcMessage is not blank
-	if rawIrcMessage == "" {
-		return nil, ErrBlankRawIrcMessage
+func (c *ChatClient) OnReconnect(handler func(message *ChatReconnectMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) reconnectDelay() time.Duration {
+	// A server requested reconnect should happen straight away
+	if c.reconnectRequested {
+		return 0
+	}
+	// Double the delay for each failed attempt up to the max delay
+	delay := reconnectMaxDelay
+	if c.reconnectAttempts < 16 {
+		delay = min(reconnectBaseDelay<<c.reconnectAttempts, reconnectMaxDelay)
 	}
-	// Create parsed IrcMessage struct
-	parsedIrcMessage := &IrcMessage{
-		Raw: rawIrcMessage,
+	// Add jitter so that a lot of clients don't all reconnect at once
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (c *ChatClient) Reply(message *ChatPrivateMessage, response string) error {
+	if c.readOnly {
+		return ErrReadOnly
 	}
-	// Split the raw irc message into sections
-	rawIrcMessageSplit := strings.Split(rawIrcMessage, " ")
-	rawIrcMessageSplitIndex := 0
-	// Check if the first section is a tags section
-	if strings.HasPrefix(rawIrcMessageSplit[rawIrcMessageSplitIndex], "@") {
-		parsedIrcMessageTags, err := c.parseRawIrcMessageTags(rawIrcMessageSplit[rawIrcMessageSplitIndex])
+	// Send the reply as part of the original message thread
+	for _, part := range c.prepareChatMessage(response) {
+		err := c.send(&IrcMessage{
+			Command: "PRIVMSG",
+			Params:  []string{message.Channel, part},
+			Tags:    replyTags(message),
+		})
 		if err != nil {
-			return nil, err
+			return err
 		}
-		parsedIrcMessage.Tags = parsedIrcMessageTags
-		rawIrcMessageSplitIndex++
 	}
-	// Message source
-	if strings.HasPrefix(rawIrcMessageSplit[rawIrcMessageSplitIndex], ":") {
-		parsedIrcMessageSource, err := c.parseRawIrcMessageSource(rawIrcMessageSplit[rawIrcMessageSplitIndex])
+	return nil
+}
+
+func (c *ChatClient) Run(ctx context.Context) error {
+	log.Println("Starting chat client")
+	// Handlers get to finish before Run returns
+	c.dispatcher.start(ctx)
+	defer c.dispatcher.stop()
+	// Streams are closed first so nothing is left blocked sending to them
+	defer c.closeEventStreams()
+	for {
+		err := c.connect(ctx)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
 		if err != nil {
-			return nil, err
+			log.Printf("Connection error: %s", err)
 		}
-		parsedIrcMessage.Source = parsedIrcMessageSource
-		rawIrcMessageSplitIndex++
-	}
-	// Message command
-	parsedIrcMessage.Command = rawIrcMessageSplit[rawIrcMessageSplitIndex]
-	rawIrcMessageSplitIndex++
-	// Remaining segments added to params
-	var parsedParams []string
-	for paramIndex, paramValue := range rawIrcMessageSplit[rawIrcMessageSplitIndex:] {
-		// If its the first index we want to remove the : if it exists
-		if paramIndex == 0 {
-			paramValue = strings.TrimPrefix(paramValue, ":")
+		// Only run the disconnect handlers if we had fully connected
+		if c.connected {
+			c.connected = false
+			c.disconnectedAt = time.Now()
+			disconnectMessage := &ChatDisconnectMessage{
+				Error: err,
+			}
+			c.emit(nil, disconnectMessage)
+		}
+		// Stop if we have been asked to shutdown
+		if ctx.Err() != nil {
+			log.Println("Chat client has stopped")
+			return ctx.Err()
+		}
+		// Wait before trying again
+		delay := c.reconnectDelay()
+		c.reconnectAttempts++
+		c.reconnectRequested = false
+		log.Printf("Reconnecting in %s", delay)
+		reconnectTimer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			reconnectTimer.Stop()
+		case <-reconnectTimer.C:
 		}
-		parsedParams = append(parsedParams, paramValue)
 	}
-	parsedIrcMessage.Params = parsedParams
-	return parsedIrcMessage, nil
 }
 
-func (c *ChatClient) parseRawIrcMessageSource(rawIrcMessageSource string) (*IrcMessageSource, error) {
-	parsedIrcMessageSource := &IrcMessageSource{}
-	rawIrcMessageSource = strings.TrimPrefix(rawIrcMessageSource, ":")
-	regex := regexp.MustCompile(`!|@`)
-	rawIrcMessageSourceSplit := regex.Split(rawIrcMessageSource, -1)
-	switch len(rawIrcMessageSourceSplit) {
-	case 1:
-		parsedIrcMessageSource.Host = rawIrcMessageSourceSplit[0]
-	case 2:
-		parsedIrcMessageSource.Nickname = rawIrcMessageSourceSplit[0]
-		parsedIrcMessageSource.Host = rawIrcMessageSourceSplit[1]
-	default:
-		parsedIrcMessageSource.Nickname = rawIrcMessageSourceSplit[0]
-		parsedIrcMessageSource.Username = rawIrcMessageSourceSplit[1]
-		parsedIrcMessageSource.Host = rawIrcMessageSourceSplit[2]
+func (c *ChatClient) Say(channel string, message string) error {
+	if c.readOnly {
+		return ErrReadOnly
 	}
-	return parsedIrcMessageSource, nil
-}
-
-func (c *ChatClient) parseRawIrcMessageTags(rawIrcMessageTags string) (map[string]string, error) {
-	parsedIrcMessageTags := make(map[string]string)
-	rawIrcMessageTags = strings.TrimPrefix(rawIrcMessageTags, "@")
-	for _, rawIrcMessageTag := range strings.Split(rawIrcMessageTags, ";") {
-		rawIrcMessageTagPairs := strings.SplitN(rawIrcMessageTag, "=", 2)
-		rawIrcMessageTagKey := rawIrcMessageTagPairs[0]
-		rawIrcMessageTagValue := rawIrcMessageTagPairs[1]
-		parsedIrcMessageTags[rawIrcMessageTagKey] = rawIrcMessageTagValue
+	channel, err := normalizeChannel(channel)
+	if err != nil {
+		return err
+	}
+	for _, part := range c.prepareChatMessage(message) {
+		err = c.send(&IrcMessage{
+			Command: "PRIVMSG",
+			Params:  []string{channel, part},
+		})
+		if err != nil {
+			return err
+		}
 	}
-	return parsedIrcMessageTags, nil
+	return nil
 }
 
-func (c *ChatClient) Reply(message *ChatPrivateMessage, response string) {
-	// Create line to send
-	line := fmt.Sprintf("@reply-parent-msg-id=%s PRIVMSG %s :%s",
-		message.Tags["id"],
-		message.Channel,
-		response,
-	)
-	// Send the line
-	c.send(line)
+func (c *ChatClient) send(message *IrcMessage) error {
+	return c.sendOutgoing(message, nil)
 }
 
-func (c *ChatClient) Say(channel string, message string) {
-	// If channel does not start with # add it
-	if !strings.HasPrefix(channel, "#") {
-		channel = fmt.Sprintf("#%s", channel)
+func (c *ChatClient) sendOutgoing(message *IrcMessage, delivery *chatDelivery) error {
+	// Build the line, this makes sure nothing can be smuggled into the message
+	line, err := message.Marshal()
+	if err != nil {
+		log.Printf("Unable to send %s: %s", message.Command, err)
+		return err
 	}
-	// Create line to send
-	line := fmt.Sprintf("PRIVMSG %s :%s", channel, message)
-	// Send the line
-	c.send(line)
-}
-
-func (c *ChatClient) send(line string) {
-	// TODO: below 2 lines are for testing and need to removed at some point
-	log.Println("Sending: " + line)
-	line = line + "\r\n"
-	c.connectionOutgoingChannel <- line
+	err = c.outgoingQueue.push(&chatOutgoingMessage{
+		delivery: delivery,
+		line:     line + "\r\n",
+		message:  message,
+	})
+	if err != nil {
+		// Run handlers if loaded
+		if hasSubscribers[*ChatQueueOverflowMessage](c) {
+			queueOverflowMessage := &ChatQueueOverflowMessage{
+				Message: message,
+			}
+			c.emit(nil, queueOverflowMessage)
+		}
+		return err
+	}
+	return nil
 }
 
 func (c *ChatClient) Start() error {
-	log.Println("Starting chat client")
-	for {
-		err := c.connect()
-		switch err {
-		default:
-			log.Println(err)
-			return err
-		}
-	}
+	return c.Run(context.Background())
 }
 
 func (c *ChatClient) startConnectionReader(wg *sync.WaitGroup, connection io.Reader) {
@@ -340,6 +507,7 @@ func (c *ChatClient) startConnectionReader(wg *sync.WaitGroup, connection io.Rea
 			// Check if there is a new line to read
 			line, err := tp.ReadLine()
 			if err != nil {
+				c.disconnectError = err
 				return
 			}
 			// Split line to make sure no multiple messages per line
@@ -353,7 +521,7 @@ func (c *ChatClient) startConnectionReader(wg *sync.WaitGroup, connection io.Rea
 	}()
 }
 
-func (c *ChatClient) startConnectionWriter(wg *sync.WaitGroup, connection io.Writer) {
+func (c *ChatClient) startConnectionWriter(wg *sync.WaitGroup, connection ChatTransport) {
 	log.Println("Starting connection writer")
 	go func() {
 		defer func() {
@@ -361,11 +529,40 @@ func (c *ChatClient) startConnectionWriter(wg *sync.WaitGroup, connection io.Wri
 			wg.Done()
 		}()
 		for {
+			// Send the next message if the rate limits allow it
+			outgoing, wait := c.outgoingQueue.pop(time.Now())
+			if outgoing != nil {
+				// Twitch only answers messages for channels we are in
+				if outgoing.message.Command == "PRIVMSG" && c.isJoined(outgoing.message.Params[0]) {
+					c.startDelivery(outgoing.message.Params[0], outgoing.delivery)
+				} else if outgoing.delivery != nil {
+					outgoing.delivery.resolve(nil, ErrNotInChannel)
+				}
+				c.bypassDuplicate(outgoing)
+				connection.Write([]byte(outgoing.line))
+				if outgoing.message.Command == "JOIN" {
+					c.startJoinTimeouts(outgoing.message)
+				}
+				continue
+			}
+			// Nothing to send yet, wait until there is
+			var waitTimer *time.Timer
+			var waitTimerChannel <-chan time.Time
+			if wait > 0 {
+				waitTimer = time.NewTimer(wait)
+				waitTimerChannel = waitTimer.C
+			}
 			select {
 			case <-c.disconnectChannel:
 				return
-			case rawIrcMessage := <-c.connectionOutgoingChannel:
-				connection.Write([]byte(rawIrcMessage))
+			case <-c.shutdownChannel:
+				c.writeShutdown(connection)
+				return
+			case <-c.outgoingQueue.notify:
+			case <-waitTimerChannel:
+			}
+			if waitTimer != nil {
+				waitTimer.Stop()
 			}
 		}
 	}()
@@ -389,7 +586,10 @@ func (c *ChatClient) startKeepAlive(wg *sync.WaitGroup, connection io.Closer) {
 				continue
 			case <-idleTimer.C:
 				// Ping the server
-				c.send(fmt.Sprintf("PING :%v", time.Now().Unix()))
+				c.send(&IrcMessage{
+					Command: "PING",
+					Params:  []string{strconv.FormatInt(time.Now().Unix(), 10)},
+				})
 				pingTimer := time.NewTimer(pingTimeout)
 				// Wait for either the server to repond with a pong or timeout
 				select {
@@ -397,6 +597,11 @@ func (c *ChatClient) startKeepAlive(wg *sync.WaitGroup, connection io.Closer) {
 					//Received pong message, connection is still good
 					pingTimer.Stop()
 					continue
+				case <-c.keepAliveReset:
+					// Anything read shows the connection is still good, the pong
+					// itself may be stuck behind a handler that is still running
+					pingTimer.Stop()
+					continue
 				case <-pingTimer.C:
 					// No pong message was received, close connection
 					connection.Close()
@@ -418,27 +623,123 @@ func (c *ChatClient) startMessageParser(wg *sync.WaitGroup) {
 			case <-c.disconnectChannel:
 				return
 			case rawIrcMessage := <-c.connectionIncommingChannel:
-				parsedIrcMessage, err := c.parseRawIrcMessage(rawIrcMessage)
-				if err != nil {
-					log.Panic(err)
-				}
-				err = c.handleParsedIrcMessage(parsedIrcMessage)
+				err := c.handleRawIrcMessage(rawIrcMessage)
 				if err != nil {
-					log.Panic(err)
+					c.reportError(err, rawIrcMessage)
 				}
 			}
 		}
 	}()
 }
 
-func NewChatClient(authProvider AuthProvider) (*ChatClient, error) {
+func (c *ChatClient) startShutdownWatcher(ctx context.Context, wg *sync.WaitGroup) {
+	go func() {
+		defer wg.Done()
+		select {
+		case <-c.disconnectChannel:
+		case <-ctx.Done():
+			log.Println("Shutting down chat connection")
+			close(c.shutdownChannel)
+		}
+	}()
+}
+
+func (c *ChatClient) writeShutdown(connection ChatTransport) {
+	// Don't let a stalled connection hold up the shutdown
+	connection.SetWriteDeadline(time.Now().Add(shutdownTimeout))
+	// Flush anything still waiting to be sent that the rate limits allow
+	for {
+		outgoing, _ := c.outgoingQueue.pop(time.Now())
+		if outgoing == nil {
+			break
+		}
+		c.bypassDuplicate(outgoing)
+		connection.Write([]byte(outgoing.line))
+	}
+	dropped := c.outgoingQueue.clear()
+	if dropped > 0 {
+		log.Printf("Dropped %d messages that were over the rate limit", dropped)
+	}
+	// Leave all channels and say goodbye
+	c.channelsMutex.Lock()
+	for channel := range c.channels {
+		partMessage := &IrcMessage{
+			Command: "PART",
+			Params:  []string{channel},
+		}
+		connection.Write([]byte(partMessage.String() + "\r\n"))
+	}
+	c.channelsMutex.Unlock()
+	quitMessage := &IrcMessage{
+		Command: "QUIT",
+	}
+	connection.Write([]byte(quitMessage.String() + "\r\n"))
+	// Closing the connection will stop the reader and everything else with it
+	connection.Close()
+}
+
+func replyTags(message *ChatPrivateMessage) map[string]string {
+	// Without an id there is no thread to reply to so send it as a normal
+	// message rather than with an empty tag
+	if message.Id == "" {
+		return nil
+	}
+	return map[string]string{
+		"reply-parent-msg-id": message.Id,
+	}
+}
+
+func sanitizeChatMessage(message string) string {
+	// Line breaks would end the message early so replace them with spaces
+	return chatMessageSanitizer.Replace(message)
+}
+
+func NewChatClient(authProvider AuthProvider, options ...ChatClientOption) (*ChatClient, error) {
+	// Create a dialer
+	netDialer := &net.Dialer{
+		KeepAlive: time.Second * 10,
+	}
+	// tls configuration
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
 	chatClient := &ChatClient{
+		address:                    serverAddress,
 		authProvider:               authProvider,
-		connectionOutgoingChannel:  make(chan string, 64),
+		channels:                   make(map[string]bool),
+		joinedChannels:             make(map[string]bool),
 		connectionIncommingChannel: make(chan string, 64),
+		deliveries:                 make(map[string][]*chatDelivery),
+		dialer:                     netDialer,
+		dispatcher:                 newChatDispatcher(),
+		eventStreams:               make(map[*chatEventStream]bool),
+		events:                     newChatEventBus(),
 		disconnectChannel:          make(chan bool),
 		keepAliveReset:             make(chan bool, 16),
+		lastMessages:               make(map[string]string),
+		pendingJoins:               make(map[string]*time.Timer),
 		pongReceived:               make(chan bool, 1),
+		rateLimits:                 ChatRateLimitsDefault,
+		state:                      newChatStateStore(),
+		tlsConfig:                  tlsConfig,
+	}
+	// Apply options
+	for _, option := range options {
+		err := option(chatClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if chatClient.authProvider == nil {
+		return nil, ErrNilAuthProvider
+	}
+	// A client without a token can only read chat
+	if _, ok := chatClient.authProvider.(*AnonymousAuthProvider); ok {
+		chatClient.readOnly = true
 	}
+	// Create the outgoing queue now the limits are known
+	chatClient.rateLimiter = newChatRateLimiter(chatClient.rateLimits)
+	chatClient.outgoingQueue = newChatOutgoingQueue(chatClient.rateLimiter)
+	chatClient.outgoingQueue.maxSize = chatClient.maxQueueSize
 	return chatClient, nil
 }
@@ -0,0 +1,196 @@
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	joinTimeout          time.Duration = time.Second * 30
+	maxChannelNameLength int           = 25
+	maxJoinLineLength    int           = 500
+)
+
+var (
+	ErrBlankChannel     error = errors.New("channel cannot be blank")
+	ErrChannelSuspended error = errors.New("channel is suspended")
+	ErrInvalidChannel   error = errors.New("channel contains invalid characters")
+	ErrJoinTimeout      error = errors.New("timed out waiting for the join to be confirmed")
+	ErrNotInChannel     error = errors.New("not in channel")
+)
+
+func (c *ChatClient) Channels() []string {
+	c.channelsMutex.Lock()
+	defer c.channelsMutex.Unlock()
+	channels := make([]string, 0, len(c.joinedChannels))
+	for channel := range c.joinedChannels {
+		channels = append(channels, channel)
+	}
+	slices.Sort(channels)
+	return channels
+}
+
+func (c *ChatClient) confirmJoin(channel string) {
+	c.channelsMutex.Lock()
+	defer c.channelsMutex.Unlock()
+	c.joinedChannels[channel] = true
+	if joinTimer, ok := c.pendingJoins[channel]; ok {
+		joinTimer.Stop()
+		delete(c.pendingJoins, channel)
+	}
+}
+
+func (c *ChatClient) confirmPart(channel string) {
+	c.channelsMutex.Lock()
+	defer c.channelsMutex.Unlock()
+	delete(c.joinedChannels, channel)
+}
+
+func (c *ChatClient) failJoin(channel string, err error) {
+	c.channelsMutex.Lock()
+	if joinTimer, ok := c.pendingJoins[channel]; ok {
+		joinTimer.Stop()
+		delete(c.pendingJoins, channel)
+	}
+	// Suspended channels can't be joined so don't try again on reconnect
+	if err == ErrChannelSuspended {
+		delete(c.channels, channel)
+	}
+	c.channelsMutex.Unlock()
+	log.Printf("Unable to join %s: %s", channel, err)
+	// Run handlers if loaded
+	if hasSubscribers[*ChatJoinFailedMessage](c) {
+		joinFailedMessage := &ChatJoinFailedMessage{
+			Channel: channel,
+			Error:   err,
+		}
+		c.emit(nil, joinFailedMessage)
+	}
+}
+
+func (c *ChatClient) Join(channel string) error {
+	return c.JoinMany(channel)
+}
+
+func (c *ChatClient) JoinMany(channels ...string) error {
+	// Make sure every channel is valid before joining any of them
+	normalizedChannels := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		normalizedChannel, err := normalizeChannel(channel)
+		if err != nil {
+			return err
+		}
+		normalizedChannels = append(normalizedChannels, normalizedChannel)
+	}
+	// Remember the channels so they can be rejoined after a reconnect
+	c.channelsMutex.Lock()
+	for _, channel := range normalizedChannels {
+		c.channels[channel] = true
+	}
+	c.channelsMutex.Unlock()
+	return c.sendJoins(normalizedChannels)
+}
+
+func (c *ChatClient) OnJoinFailed(handler func(message *ChatJoinFailedMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) Part(channel string) error {
+	channel, err := normalizeChannel(channel)
+	if err != nil {
+		return err
+	}
+	// Forget the channel so it isn't rejoined after a reconnect
+	c.channelsMutex.Lock()
+	delete(c.channels, channel)
+	if joinTimer, ok := c.pendingJoins[channel]; ok {
+		joinTimer.Stop()
+		delete(c.pendingJoins, channel)
+	}
+	c.channelsMutex.Unlock()
+	return c.send(&IrcMessage{
+		Command: "PART",
+		Params:  []string{channel},
+	})
+}
+
+func (c *ChatClient) resetChannels() {
+	c.channelsMutex.Lock()
+	defer c.channelsMutex.Unlock()
+	for _, joinTimer := range c.pendingJoins {
+		joinTimer.Stop()
+	}
+	c.joinedChannels = make(map[string]bool)
+	c.pendingJoins = make(map[string]*time.Timer)
+}
+
+func (c *ChatClient) sendJoins(channels []string) error {
+	// Join as many channels as the rate limits allow in a single line
+	batch := []string{}
+	batchLength := 0
+	for _, channel := range channels {
+		if len(batch) > 0 && (len(batch) >= c.rateLimits.JoinLimit || batchLength+len(channel)+1 > maxJoinLineLength) {
+			err := c.send(&IrcMessage{
+				Command: "JOIN",
+				Params:  []string{strings.Join(batch, ",")},
+			})
+			if err != nil {
+				return err
+			}
+			batch = []string{}
+			batchLength = 0
+		}
+		batch = append(batch, channel)
+		batchLength += len(channel) + 1
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	return c.send(&IrcMessage{
+		Command: "JOIN",
+		Params:  []string{strings.Join(batch, ",")},
+	})
+}
+
+func (c *ChatClient) startJoinTimeouts(joinMessage *IrcMessage) {
+	c.channelsMutex.Lock()
+	defer c.channelsMutex.Unlock()
+	// The timeout starts once the JOIN has actually been sent
+	for _, channel := range strings.Split(joinMessage.Params[0], ",") {
+		if c.joinedChannels[channel] {
+			continue
+		}
+		if joinTimer, ok := c.pendingJoins[channel]; ok {
+			joinTimer.Stop()
+		}
+		var joinTimer *time.Timer
+		joinTimer = time.AfterFunc(joinTimeout, func() {
+			c.channelsMutex.Lock()
+			timedOut := c.pendingJoins[channel] == joinTimer
+			c.channelsMutex.Unlock()
+			if timedOut {
+				c.failJoin(channel, ErrJoinTimeout)
+			}
+		})
+		c.pendingJoins[channel] = joinTimer
+	}
+}
+
+func normalizeChannel(channel string) (string, error) {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	channel = strings.TrimPrefix(channel, "#")
+	if channel == "" {
+		return "", ErrBlankChannel
+	}
+	// Channel names are the same as logins
+	if len(channel) > maxChannelNameLength || strings.IndexFunc(channel, func(character rune) bool {
+		return !isLoginCharacter(character)
+	}) != -1 {
+		return "", ErrInvalidChannel
+	}
+	return fmt.Sprintf("#%s", channel), nil
+}
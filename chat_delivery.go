@@ -0,0 +1,184 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	deliveryTimeout time.Duration = time.Second * 10
+)
+
+var (
+	ErrDeliveryDisconnected error = errors.New("disconnected before the message was acknowledged")
+	ErrDeliveryTimeout      error = errors.New("timed out waiting for the message to be acknowledged")
+	ErrMessageBanned        error = errors.New("banned from the channel")
+	ErrMessageDropped       error = errors.New("message was dropped before it was sent")
+	ErrMessageDuplicate     error = errors.New("message is identical to the previous one")
+	ErrMessageFollowersOnly error = errors.New("channel is in followers only mode")
+	ErrMessageRejected      error = errors.New("message was rejected")
+	ErrMessageSlowMode      error = errors.New("channel is in slow mode")
+)
+
+type ChatSendError struct {
+	Notice *ChatNoticeMessage
+}
+
+func (e *ChatSendError) Error() string {
+	return e.Unwrap().Error() + ": " + e.Notice.Message
+}
+
+func (e *ChatSendError) Unwrap() error {
+	switch {
+	case e.Notice.Type == ChatNoticeTypeMessageBanned:
+		return ErrMessageBanned
+	case e.Notice.Type == ChatNoticeTypeMessageDuplicate:
+		return ErrMessageDuplicate
+	case strings.HasPrefix(string(e.Notice.Type), string(ChatNoticeTypeMessageFollowersOnly)):
+		// Followers only has variants for zero and non zero follow times
+		return ErrMessageFollowersOnly
+	case e.Notice.Type == ChatNoticeTypeMessageSlowMode:
+		return ErrMessageSlowMode
+	default:
+		return ErrMessageRejected
+	}
+}
+
+type chatDelivery struct {
+	done   chan bool
+	err    error
+	result *ChatSendResult
+	timer  *time.Timer
+}
+
+func (d *chatDelivery) resolve(result *ChatSendResult, err error) {
+	select {
+	case <-d.done:
+		// Already resolved
+	default:
+		d.result = result
+		d.err = err
+		close(d.done)
+	}
+}
+
+func (c *ChatClient) failDeliveries(err error) {
+	c.deliveriesMutex.Lock()
+	defer c.deliveriesMutex.Unlock()
+	for _, deliveries := range c.deliveries {
+		for _, delivery := range deliveries {
+			if delivery == nil {
+				continue
+			}
+			delivery.timer.Stop()
+			delivery.resolve(nil, err)
+		}
+	}
+	c.deliveries = make(map[string][]*chatDelivery)
+}
+
+func (c *ChatClient) isJoined(channel string) bool {
+	c.channelsMutex.Lock()
+	defer c.channelsMutex.Unlock()
+	return c.joinedChannels[channel]
+}
+
+func (c *ChatClient) ReplyWithResult(ctx context.Context, message *ChatPrivateMessage, response string) (*ChatSendResult, error) {
+	if c.readOnly {
+		return nil, ErrReadOnly
+	}
+	return c.sendPartsWithResult(ctx, message.Channel, response, replyTags(message))
+}
+
+func (c *ChatClient) resolveDelivery(channel string, result *ChatSendResult, err error) {
+	c.deliveriesMutex.Lock()
+	defer c.deliveriesMutex.Unlock()
+	// Twitch answers messages in the order they were sent so the oldest
+	// waiting message is the one being answered
+	deliveries := c.deliveries[channel]
+	if len(deliveries) == 0 {
+		return
+	}
+	delivery := deliveries[0]
+	c.deliveries[channel] = deliveries[1:]
+	// Messages sent without waiting for an answer still take their place
+	if delivery == nil {
+		return
+	}
+	delivery.timer.Stop()
+	delivery.resolve(result, err)
+}
+
+func (c *ChatClient) SayWithResult(ctx context.Context, channel string, message string) (*ChatSendResult, error) {
+	if c.readOnly {
+		return nil, ErrReadOnly
+	}
+	channel, err := normalizeChannel(channel)
+	if err != nil {
+		return nil, err
+	}
+	return c.sendPartsWithResult(ctx, channel, message, nil)
+}
+
+func (c *ChatClient) sendPartsWithResult(ctx context.Context, channel string, message string, tags map[string]string) (*ChatSendResult, error) {
+	// Each part waits for the one before so a failure stops the rest
+	var result *ChatSendResult
+	for _, part := range c.prepareChatMessage(message) {
+		var err error
+		result, err = c.sendWithResult(ctx, &IrcMessage{
+			Tags:    tags,
+			Command: "PRIVMSG",
+			Params:  []string{channel, part},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (c *ChatClient) sendWithResult(ctx context.Context, message *IrcMessage) (*ChatSendResult, error) {
+	channel := message.Params[0]
+	// Twitch silently drops messages for channels we are not in
+	if !c.isJoined(channel) {
+		return nil, ErrNotInChannel
+	}
+	delivery := &chatDelivery{
+		done: make(chan bool),
+	}
+	err := c.sendOutgoing(message, delivery)
+	if err != nil {
+		return nil, err
+	}
+	// The answer is handled by the parser, which may be waiting on the handler
+	// that sent this message, so let it carry on without it
+	c.dispatcher.release()
+	// Giving up early leaves the delivery waiting so that the answer to this
+	// message isn't mistaken for the answer to the next one
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-delivery.done:
+		return delivery.result, delivery.err
+	}
+}
+
+func (c *ChatClient) startDelivery(channel string, delivery *chatDelivery) {
+	c.deliveriesMutex.Lock()
+	defer c.deliveriesMutex.Unlock()
+	// Every message written is answered so they all need a place in the
+	// order, even the ones nothing is waiting on
+	if delivery != nil {
+		// The timeout starts once the message has actually been written as it
+		// may have been held in the queue by the rate limits. The delivery keeps
+		// its place so that a late answer isn't given to the next message
+		delivery.timer = time.AfterFunc(deliveryTimeout, func() {
+			c.deliveriesMutex.Lock()
+			defer c.deliveriesMutex.Unlock()
+			delivery.resolve(nil, ErrDeliveryTimeout)
+		})
+	}
+	c.deliveries[channel] = append(c.deliveries[channel], delivery)
+}
@@ -0,0 +1,155 @@
+package twitch
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dispatchQueueSize int = 64
+)
+
+const (
+	chatDispatchSync chatDispatchMode = iota
+	chatDispatchGoroutine
+	chatDispatchWorkerPool
+)
+
+type chatDispatchMode int
+
+type chatDispatcher struct {
+	ctx          context.Context
+	mode         chatDispatchMode
+	mutex        sync.RWMutex
+	queues       []chan func()
+	released     chan bool
+	releaseMutex sync.Mutex
+	running      bool
+	timeout      time.Duration
+	wg           sync.WaitGroup
+	workers      int
+}
+
+func (d *chatDispatcher) dispatch(key string, job func()) {
+	d.mutex.RLock()
+	// Nothing is running the handlers outside of Run so do it here
+	if !d.running {
+		d.mutex.RUnlock()
+		job()
+		return
+	}
+	switch d.mode {
+	case chatDispatchGoroutine:
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			job()
+		}()
+	case chatDispatchWorkerPool:
+		// Everything for a channel goes to the same worker so it stays in order
+		hash := fnv.New32a()
+		hash.Write([]byte(key))
+		d.queues[hash.Sum32()%uint32(len(d.queues))] <- job
+	default:
+		d.mutex.RUnlock()
+		d.wait(job)
+		return
+	}
+	d.mutex.RUnlock()
+}
+
+func (d *chatDispatcher) handlerContext() (context.Context, context.CancelFunc) {
+	d.mutex.RLock()
+	ctx := d.ctx
+	d.mutex.RUnlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if d.timeout > 0 {
+		return context.WithTimeout(ctx, d.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func (d *chatDispatcher) release() {
+	// Stop waiting on every handler that is running right now
+	d.releaseMutex.Lock()
+	defer d.releaseMutex.Unlock()
+	close(d.released)
+	d.released = make(chan bool)
+}
+
+func (d *chatDispatcher) start(ctx context.Context) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.ctx = ctx
+	d.running = true
+	if d.mode != chatDispatchWorkerPool {
+		return
+	}
+	d.queues = make([]chan func(), d.workers)
+	for index := range d.queues {
+		queue := make(chan func(), dispatchQueueSize)
+		d.queues[index] = queue
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for job := range queue {
+				d.wait(job)
+			}
+		}()
+	}
+}
+
+func (d *chatDispatcher) stop() {
+	d.mutex.Lock()
+	d.running = false
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.queues = nil
+	d.mutex.Unlock()
+	// Let anything already dispatched finish
+	d.wg.Wait()
+	d.mutex.Lock()
+	d.ctx = nil
+	d.mutex.Unlock()
+}
+
+func (d *chatDispatcher) wait(job func()) {
+	d.releaseMutex.Lock()
+	released := d.released
+	d.releaseMutex.Unlock()
+	// The job runs on its own so that whatever dispatched it can stop waiting
+	// if the job is itself waiting on a message that has to be handled first
+	done := make(chan bool)
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer close(done)
+		job()
+	}()
+	select {
+	case <-done:
+	case <-released:
+	}
+}
+
+func dispatchKey(parsedIrcMessage *IrcMessage) string {
+	// Channel events are kept in order per channel, everything else shares
+	// a single key so it is kept in order too
+	if len(parsedIrcMessage.Params) > 0 && strings.HasPrefix(parsedIrcMessage.Params[0], "#") {
+		return parsedIrcMessage.Params[0]
+	}
+	return ""
+}
+
+func newChatDispatcher() *chatDispatcher {
+	return &chatDispatcher{
+		mode:     chatDispatchSync,
+		released: make(chan bool),
+	}
+}
@@ -0,0 +1,113 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+var (
+	ErrHandlerPanic     error = errors.New("handler panicked")
+	ErrHandlerTimeout   error = errors.New("handler ran past its timeout")
+	ErrMissingIrcParams error = errors.New("irc message is missing params")
+	ErrMissingIrcSource error = errors.New("irc message is missing a source")
+)
+
+type ircCommandRequirements struct {
+	params int
+	source bool
+}
+
+var (
+	// What each command needs before it can be handled without going out of
+	// bounds, anything not listed has no requirements
+	chatCommandRequirements map[string]ircCommandRequirements = map[string]ircCommandRequirements{
+		"CLEARCHAT":  {params: 1},
+		"CLEARMSG":   {params: 1},
+		"JOIN":       {params: 1, source: true},
+		"NOTICE":     {params: 1},
+		"PART":       {params: 1, source: true},
+		"PING":       {params: 1},
+		"PONG":       {params: 1},
+		"PRIVMSG":    {params: 2, source: true},
+		"ROOMSTATE":  {params: 1},
+		"USERNOTICE": {params: 1},
+		"USERSTATE":  {params: 1},
+		"WHISPER":    {params: 1, source: true},
+	}
+)
+
+func (c *ChatClient) handleRawIrcMessage(rawIrcMessage string) (err error) {
+	// Anything that goes wrong handling a single message must not take down
+	// the connection with it
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("Recovered from panic: %v\n%s", recovered, debug.Stack())
+			err = fmt.Errorf("%w: %v", ErrHandlerPanic, recovered)
+		}
+	}()
+	parsedIrcMessage, err := ParseIrcMessage(rawIrcMessage)
+	if err != nil {
+		return err
+	}
+	// Raw handlers see everything, including commands that aren't modelled
+	c.emitRaw(parsedIrcMessage)
+	err = validateIrcMessage(parsedIrcMessage)
+	if err != nil {
+		return err
+	}
+	return c.handleParsedIrcMessage(parsedIrcMessage)
+}
+
+func (c *ChatClient) OnError(handler func(err error, raw string)) {
+	c.events.subscribeError(handler)
+}
+
+func (c *ChatClient) reportError(err error, raw string) {
+	subscriptions := c.events.errorSubscribers()
+	if len(subscriptions) == 0 {
+		log.Printf("Unable to handle message: %s [%s]", err, raw)
+		return
+	}
+	for _, subscription := range subscriptions {
+		// A broken error handler has nowhere left to report to
+		func() {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					log.Printf("Recovered from panic in error handler: %v", recovered)
+				}
+			}()
+			subscription.handler(err, raw)
+		}()
+	}
+}
+
+func (c *ChatClient) runHandler(raw string, handler func(ctx context.Context)) {
+	ctx, cancel := c.dispatcher.handlerContext()
+	defer cancel()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("Recovered from panic: %v\n%s", recovered, debug.Stack())
+			c.reportError(fmt.Errorf("%w: %v", ErrHandlerPanic, recovered), raw)
+		}
+	}()
+	handler(ctx)
+	// Handlers can't be stopped, the best that can be done is letting
+	// someone know it happened
+	if ctx.Err() == context.DeadlineExceeded {
+		c.reportError(ErrHandlerTimeout, raw)
+	}
+}
+
+func validateIrcMessage(parsedIrcMessage *IrcMessage) error {
+	requirements := chatCommandRequirements[parsedIrcMessage.Command]
+	if len(parsedIrcMessage.Params) < requirements.params {
+		return fmt.Errorf("%w: %s needs %d", ErrMissingIrcParams, parsedIrcMessage.Command, requirements.params)
+	}
+	if requirements.source && parsedIrcMessage.Source == nil {
+		return fmt.Errorf("%w: %s", ErrMissingIrcSource, parsedIrcMessage.Command)
+	}
+	return nil
+}
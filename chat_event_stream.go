@@ -0,0 +1,128 @@
+package twitch
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	defaultEventStreamBuffer int = 64
+)
+
+const (
+	ChatEventPolicyDrop ChatEventPolicy = iota
+	ChatEventPolicyBlock
+)
+
+type ChatEventFilter func(event Event) bool
+
+type ChatEventPolicy int
+
+type ChatEventStreamOption func(stream *chatEventStream)
+
+type chatEventStream struct {
+	buffer      int
+	closeOnce   sync.Once
+	done        chan bool
+	events      chan Event
+	filter      ChatEventFilter
+	mutex       sync.RWMutex
+	policy      ChatEventPolicy
+	stopCtx     func() bool
+	unsubscribe func()
+}
+
+func (s *chatEventStream) close() {
+	s.closeOnce.Do(func() {
+		// Wake up anything blocked sending before closing the channel
+		close(s.done)
+		s.mutex.Lock()
+		close(s.events)
+		// The context may have ended before the stream finished being set up
+		stopCtx := s.stopCtx
+		s.mutex.Unlock()
+		s.unsubscribe()
+		if stopCtx != nil {
+			stopCtx()
+		}
+	})
+}
+
+func (s *chatEventStream) send(event Event) {
+	if s.filter != nil && !s.filter(event) {
+		return
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	if s.policy == ChatEventPolicyBlock {
+		select {
+		case s.events <- event:
+		case <-s.done:
+		}
+		return
+	}
+	// Drop the event rather than hold up chat if nobody is reading
+	select {
+	case s.events <- event:
+	default:
+	}
+}
+
+func (c *ChatClient) closeEventStreams() {
+	c.eventStreamsMutex.Lock()
+	streams := c.eventStreams
+	c.eventStreams = make(map[*chatEventStream]bool)
+	c.eventStreamsMutex.Unlock()
+	for stream := range streams {
+		stream.close()
+	}
+}
+
+func (c *ChatClient) Events(ctx context.Context, filter ChatEventFilter, options ...ChatEventStreamOption) <-chan Event {
+	stream := &chatEventStream{
+		buffer: defaultEventStreamBuffer,
+		done:   make(chan bool),
+		filter: filter,
+		policy: ChatEventPolicyDrop,
+	}
+	for _, option := range options {
+		option(stream)
+	}
+	stream.events = make(chan Event, max(stream.buffer, 0))
+	stream.unsubscribe = c.events.subscribe(nil, func(ctx context.Context, event Event) {
+		stream.send(event)
+	})
+	// Add the stream before watching the context as an already finished
+	// context removes it straight away
+	c.eventStreamsMutex.Lock()
+	c.eventStreams[stream] = true
+	c.eventStreamsMutex.Unlock()
+	// The stream ends with the context or when the client stops running
+	stopCtx := context.AfterFunc(ctx, func() {
+		c.eventStreamsMutex.Lock()
+		delete(c.eventStreams, stream)
+		c.eventStreamsMutex.Unlock()
+		stream.close()
+	})
+	stream.mutex.Lock()
+	stream.stopCtx = stopCtx
+	stream.mutex.Unlock()
+	return stream.events
+}
+
+func WithEventBuffer(buffer int) ChatEventStreamOption {
+	return func(stream *chatEventStream) {
+		stream.buffer = buffer
+	}
+}
+
+func WithEventPolicy(policy ChatEventPolicy) ChatEventStreamOption {
+	return func(stream *chatEventStream) {
+		stream.policy = policy
+	}
+}
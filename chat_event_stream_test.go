@@ -0,0 +1,51 @@
+package twitch
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func waitForEventStreamClose(t *testing.T, events <-chan Event) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for the stream to close")
+		}
+	}
+}
+
+func TestEventsContextDone(t *testing.T) {
+	tests := map[string]bool{
+		"cancelled before":     true,
+		"cancelled while open": false,
+	}
+	for name, cancelBefore := range tests {
+		t.Run(name, func(t *testing.T) {
+			chat, err := NewChatClient(NewAnonymousProvider())
+			if err != nil {
+				t.Fatalf("unable to create client: %s", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			if cancelBefore {
+				cancel()
+			}
+			events := chat.Events(ctx, nil)
+			cancel()
+			waitForEventStreamClose(t, events)
+			// The stream is removed once it has closed so it isn't leaked
+			chat.eventStreamsMutex.Lock()
+			streams := len(chat.eventStreams)
+			chat.eventStreamsMutex.Unlock()
+			if streams != 0 {
+				t.Errorf("%d streams left open, want 0", streams)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package twitch
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+type chatEventBus struct {
+	anyHandlers   []*chatEventSubscription
+	errorHandlers []*chatErrorSubscription
+	handlers      map[reflect.Type][]*chatEventSubscription
+	mutex         sync.RWMutex
+	rawHandlers   []*chatRawSubscription
+}
+
+type chatErrorSubscription struct {
+	handler func(err error, raw string)
+}
+
+type chatEventSubscription struct {
+	handler func(ctx context.Context, event Event)
+}
+
+type chatRawSubscription struct {
+	handler func(ctx context.Context, message *IrcMessage)
+}
+
+func (b *chatEventBus) errorSubscribers() []*chatErrorSubscription {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.errorHandlers
+}
+
+func (b *chatEventBus) hasSubscribers(eventType reflect.Type) bool {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return len(b.handlers[eventType]) > 0 || len(b.anyHandlers) > 0
+}
+
+func (b *chatEventBus) rawSubscribers() []*chatRawSubscription {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.rawHandlers
+}
+
+func (b *chatEventBus) subscribe(eventType reflect.Type, handler func(ctx context.Context, event Event)) func() {
+	subscription := &chatEventSubscription{
+		handler: handler,
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	// A nil type means every event
+	if eventType == nil {
+		b.anyHandlers = append(b.anyHandlers, subscription)
+	} else {
+		b.handlers[eventType] = append(b.handlers[eventType], subscription)
+	}
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		isSubscription := func(existing *chatEventSubscription) bool {
+			return existing == subscription
+		}
+		// Slices are replaced rather than changed so anything already
+		// iterating over the old ones isn't affected
+		if eventType == nil {
+			b.anyHandlers = slices.DeleteFunc(slices.Clone(b.anyHandlers), isSubscription)
+		} else {
+			b.handlers[eventType] = slices.DeleteFunc(slices.Clone(b.handlers[eventType]), isSubscription)
+		}
+	}
+}
+
+func (b *chatEventBus) subscribeError(handler func(err error, raw string)) func() {
+	subscription := &chatErrorSubscription{
+		handler: handler,
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.errorHandlers = append(b.errorHandlers, subscription)
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		b.errorHandlers = slices.DeleteFunc(slices.Clone(b.errorHandlers), func(existing *chatErrorSubscription) bool {
+			return existing == subscription
+		})
+	}
+}
+
+func (b *chatEventBus) subscribeRaw(handler func(ctx context.Context, message *IrcMessage)) func() {
+	subscription := &chatRawSubscription{
+		handler: handler,
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.rawHandlers = append(b.rawHandlers, subscription)
+	return func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		b.rawHandlers = slices.DeleteFunc(slices.Clone(b.rawHandlers), func(existing *chatRawSubscription) bool {
+			return existing == subscription
+		})
+	}
+}
+
+func (b *chatEventBus) subscribers(eventType reflect.Type) []*chatEventSubscription {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	// Handlers for the type run before the catch all ones
+	return slices.Concat(b.handlers[eventType], b.anyHandlers)
+}
+
+func (c *ChatClient) emit(parsedIrcMessage *IrcMessage, event Event) {
+	subscriptions := c.events.subscribers(reflect.TypeOf(event))
+	if len(subscriptions) == 0 {
+		return
+	}
+	run := func(raw string) {
+		// Each handler is run on its own so one panicking doesn't stop the rest
+		for _, subscription := range subscriptions {
+			c.runHandler(raw, func(ctx context.Context) {
+				subscription.handler(ctx, event)
+			})
+		}
+	}
+	// Events that didn't come from the server are run straight away by
+	// whatever caused them
+	if parsedIrcMessage == nil {
+		run("")
+		return
+	}
+	c.dispatcher.dispatch(dispatchKey(parsedIrcMessage), func() {
+		run(parsedIrcMessage.Raw)
+	})
+}
+
+func (c *ChatClient) emitRaw(parsedIrcMessage *IrcMessage) {
+	subscriptions := c.events.rawSubscribers()
+	if len(subscriptions) == 0 {
+		return
+	}
+	c.dispatcher.dispatch(dispatchKey(parsedIrcMessage), func() {
+		for _, subscription := range subscriptions {
+			c.runHandler(parsedIrcMessage.Raw, func(ctx context.Context) {
+				subscription.handler(ctx, parsedIrcMessage)
+			})
+		}
+	})
+}
+
+func hasSubscribers[T Event](c *ChatClient) bool {
+	return c.events.hasSubscribers(reflect.TypeFor[T]())
+}
+
+func (c *ChatClient) OnAny(handler func(event Event)) func() {
+	return c.events.subscribe(nil, func(ctx context.Context, event Event) {
+		handler(event)
+	})
+}
+
+func (c *ChatClient) OnRaw(handler func(message *IrcMessage)) func() {
+	return c.events.subscribeRaw(func(ctx context.Context, message *IrcMessage) {
+		handler(message)
+	})
+}
+
+func (c *ChatClient) subscribe(eventType reflect.Type, handler func(ctx context.Context, event Event)) func() {
+	return c.events.subscribe(eventType, handler)
+}
+
+func On[T Event](source EventSource, handler func(event T)) func() {
+	return source.subscribe(reflect.TypeFor[T](), func(ctx context.Context, event Event) {
+		handler(event.(T))
+	})
+}
+
+func OnContext[T Event](source EventSource, handler func(ctx context.Context, event T)) func() {
+	return source.subscribe(reflect.TypeFor[T](), func(ctx context.Context, event Event) {
+		handler(ctx, event.(T))
+	})
+}
+
+func newChatEventBus() *chatEventBus {
+	return &chatEventBus{
+		handlers: make(map[reflect.Type][]*chatEventSubscription),
+	}
+}
+
+func (*ChatAnnouncementMessage) isChatEvent()    {}
+func (*ChatBitsBadgeTierMessage) isChatEvent()   {}
+func (*ChatClearChatMessage) isChatEvent()       {}
+func (*ChatClearMessageMessage) isChatEvent()    {}
+func (*ChatConnectMessage) isChatEvent()         {}
+func (*ChatDisconnectMessage) isChatEvent()      {}
+func (*ChatGlobalUserStateMessage) isChatEvent() {}
+func (*ChatJoinFailedMessage) isChatEvent()      {}
+func (*ChatJoinMessage) isChatEvent()            {}
+func (*ChatNoticeMessage) isChatEvent()          {}
+func (*ChatPartMessage) isChatEvent()            {}
+func (*ChatPingMessage) isChatEvent()            {}
+func (*ChatPongMessage) isChatEvent()            {}
+func (*ChatPrivateMessage) isChatEvent()         {}
+func (*ChatQueueOverflowMessage) isChatEvent()   {}
+func (*ChatRaidMessage) isChatEvent()            {}
+func (*ChatReconnectMessage) isChatEvent()       {}
+func (*ChatRitualMessage) isChatEvent()          {}
+func (*ChatRoomStateMessage) isChatEvent()       {}
+func (*ChatSubGiftMessage) isChatEvent()         {}
+func (*ChatSubMessage) isChatEvent()             {}
+func (*ChatSubMysteryGiftMessage) isChatEvent()  {}
+func (*ChatUserNoticeMessage) isChatEvent()      {}
+func (*ChatUserStateMessage) isChatEvent()       {}
+func (*ChatWhisperMessage) isChatEvent()         {}
@@ -0,0 +1,175 @@
+package twitch
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+const (
+	ChatFragmentTypeCheermote ChatFragmentType = "cheermote"
+	ChatFragmentTypeEmote     ChatFragmentType = "emote"
+	ChatFragmentTypeMention   ChatFragmentType = "mention"
+	ChatFragmentTypeText      ChatFragmentType = "text"
+	emoteUrlTemplate          string           = "https://static-cdn.jtvnw.net/emoticons/v2/{{id}}/{{format}}/{{theme_mode}}/{{scale}}"
+)
+
+var (
+	DefaultCheermotePrefixes []string = []string{
+		"Anon", "BibleThump", "Cheer", "cheerwhal", "Corgo", "DoodleCheer",
+		"FailFish", "FrankerZ", "Kappa", "Kreygasm", "MrDestructoid", "Muxy",
+		"NotLikeThis", "Party", "PJSalt", "Pride", "RIPCheer", "Scoops",
+		"SeemsGood", "ShowLove", "Streamlabs", "SwiftRage", "TriHard", "uni",
+		"VoHiYo",
+	}
+)
+
+func (f *ChatFragment) EmoteUrl(format string, themeMode string, scale string) string {
+	if f.Type != ChatFragmentTypeEmote {
+		return ""
+	}
+	return strings.NewReplacer(
+		"{{id}}", f.EmoteId,
+		"{{format}}", format,
+		"{{theme_mode}}", themeMode,
+		"{{scale}}", scale,
+	).Replace(f.UrlTemplate)
+}
+
+func (m *ChatPrivateMessage) Fragments() []ChatFragment {
+	return m.FragmentsWithCheermotes(DefaultCheermotePrefixes)
+}
+
+func (m *ChatPrivateMessage) FragmentsWithCheermotes(cheermotePrefixes []string) []ChatFragment {
+	// Cheermotes only count if the message actually contained bits
+	if m.Bits <= 0 {
+		cheermotePrefixes = nil
+	}
+	var fragments []ChatFragment
+	// Emote positions are in characters so work with runes
+	messageRunes := []rune(m.Message)
+	position := 0
+	for _, emote := range m.Emotes {
+		// Ignore any emotes that overlap with the previous one
+		if emote.Start < position || emote.End > len(messageRunes) {
+			continue
+		}
+		fragments = appendTextFragments(fragments, string(messageRunes[position:emote.Start]), cheermotePrefixes)
+		fragments = append(fragments, ChatFragment{
+			EmoteId:     emote.Id,
+			Text:        emote.Name,
+			Type:        ChatFragmentTypeEmote,
+			UrlTemplate: emoteUrlTemplate,
+		})
+		position = emote.End
+	}
+	return appendTextFragments(fragments, string(messageRunes[position:]), cheermotePrefixes)
+}
+
+func (m *ChatPrivateMessage) HTML() string {
+	return RenderFragmentsHTML(m.Fragments())
+}
+
+func appendTextFragments(fragments []ChatFragment, text string, cheermotePrefixes []string) []ChatFragment {
+	textFragment := &strings.Builder{}
+	flush := func() {
+		if textFragment.Len() > 0 {
+			fragments = append(fragments, ChatFragment{
+				Text: textFragment.String(),
+				Type: ChatFragmentTypeText,
+			})
+			textFragment.Reset()
+		}
+	}
+	// Mentions and cheermotes are always whole words
+	for index, word := range strings.Split(text, " ") {
+		if index > 0 {
+			textFragment.WriteString(" ")
+		}
+		wordFragment, remaining, ok := parseWordFragment(word, cheermotePrefixes)
+		if !ok {
+			textFragment.WriteString(word)
+			continue
+		}
+		flush()
+		fragments = append(fragments, wordFragment)
+		textFragment.WriteString(remaining)
+	}
+	flush()
+	return fragments
+}
+
+func isLoginCharacter(character rune) bool {
+	return character == '_' ||
+		(character >= 'a' && character <= 'z') ||
+		(character >= 'A' && character <= 'Z') ||
+		(character >= '0' && character <= '9')
+}
+
+func parseWordFragment(word string, cheermotePrefixes []string) (ChatFragment, string, bool) {
+	// Mentions are @ followed by a login, anything after the login such as
+	// punctuation is left as text
+	if strings.HasPrefix(word, "@") {
+		loginLength := strings.IndexFunc(word[1:], func(character rune) bool {
+			return !isLoginCharacter(character)
+		})
+		if loginLength == -1 {
+			loginLength = len(word) - 1
+		}
+		if loginLength > 0 {
+			return ChatFragment{
+				Login: strings.ToLower(word[1 : loginLength+1]),
+				Text:  word[:loginLength+1],
+				Type:  ChatFragmentTypeMention,
+			}, word[loginLength+1:], true
+		}
+		return ChatFragment{}, "", false
+	}
+	// Cheermotes are a known prefix followed by the number of bits
+	for _, cheermotePrefix := range cheermotePrefixes {
+		if len(word) <= len(cheermotePrefix) || !strings.EqualFold(word[:len(cheermotePrefix)], cheermotePrefix) {
+			continue
+		}
+		bits, err := strconv.Atoi(word[len(cheermotePrefix):])
+		if err != nil || bits <= 0 {
+			continue
+		}
+		return ChatFragment{
+			Bits:   bits,
+			Prefix: cheermotePrefix,
+			Text:   word,
+			Type:   ChatFragmentTypeCheermote,
+		}, "", true
+	}
+	return ChatFragment{}, "", false
+}
+
+func RenderFragmentsHTML(fragments []ChatFragment) string {
+	rendered := &strings.Builder{}
+	for _, fragment := range fragments {
+		// Everything that came from chat must be escaped
+		text := html.EscapeString(fragment.Text)
+		switch fragment.Type {
+		case ChatFragmentTypeCheermote:
+			fmt.Fprintf(rendered, `<span class="cheermote" data-prefix="%s" data-bits="%d">%s</span>`,
+				html.EscapeString(fragment.Prefix),
+				fragment.Bits,
+				text,
+			)
+		case ChatFragmentTypeEmote:
+			fmt.Fprintf(rendered, `<img class="emote" src="%s" alt="%s">`,
+				html.EscapeString(fragment.EmoteUrl("default", "dark", "1.0")),
+				text,
+			)
+		case ChatFragmentTypeMention:
+			fmt.Fprintf(rendered, `<span class="mention" data-login="%s">%s</span>`,
+				html.EscapeString(fragment.Login),
+				text,
+			)
+		default:
+			rendered.WriteString(text)
+		}
+	}
+	return rendered.String()
+}
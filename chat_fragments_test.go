@@ -0,0 +1,189 @@
+package twitch
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseEmotesTag(t *testing.T) {
+	tests := map[string]struct {
+		emotes   string
+		message  string
+		expected []EmoteRange
+	}{
+		"no emotes": {
+			emotes:  "",
+			message: "hello",
+		},
+		"emoji before emote": {
+			emotes:  "25:2-6",
+			message: "😀 Kappa",
+			expected: []EmoteRange{
+				{End: 7, Id: "25", Name: "Kappa", Start: 2},
+			},
+		},
+		"sorted by position": {
+			emotes:  "25:12-16/1902:0-4,6-10",
+			message: "Keepo Keepo Kappa",
+			expected: []EmoteRange{
+				{End: 5, Id: "1902", Name: "Keepo", Start: 0},
+				{End: 11, Id: "1902", Name: "Keepo", Start: 6},
+				{End: 17, Id: "25", Name: "Kappa", Start: 12},
+			},
+		},
+		"out of range": {
+			emotes:  "25:0-4,6-20",
+			message: "Kappa hi",
+			expected: []EmoteRange{
+				{End: 5, Id: "25", Name: "Kappa", Start: 0},
+			},
+		},
+		"reversed and negative": {
+			emotes:  "25:4-0,-1-3",
+			message: "Kappa",
+		},
+		"malformed": {
+			emotes:  "25/25:a-4,0-b,3/:0-4",
+			message: "Kappa",
+			expected: []EmoteRange{
+				{End: 5, Id: "", Name: "Kappa", Start: 0},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			emotes := parseEmotesTag(test.emotes, test.message)
+			if !slices.Equal(emotes, test.expected) {
+				t.Errorf("got %+v, want %+v", emotes, test.expected)
+			}
+		})
+	}
+}
+
+func TestFragmentsWithCheermotes(t *testing.T) {
+	tests := map[string]struct {
+		message  *ChatPrivateMessage
+		expected []ChatFragment
+	}{
+		"text": {
+			message: &ChatPrivateMessage{Message: "hello world"},
+			expected: []ChatFragment{
+				{Text: "hello world", Type: ChatFragmentTypeText},
+			},
+		},
+		"emoji before emote": {
+			message: &ChatPrivateMessage{
+				Emotes:  []EmoteRange{{End: 7, Id: "25", Name: "Kappa", Start: 2}},
+				Message: "😀 Kappa!",
+			},
+			expected: []ChatFragment{
+				{Text: "😀 ", Type: ChatFragmentTypeText},
+				{EmoteId: "25", Text: "Kappa", Type: ChatFragmentTypeEmote, UrlTemplate: emoteUrlTemplate},
+				{Text: "!", Type: ChatFragmentTypeText},
+			},
+		},
+		"overlapping emotes": {
+			message: &ChatPrivateMessage{
+				Emotes: []EmoteRange{
+					{End: 5, Id: "25", Name: "Kappa", Start: 0},
+					{End: 7, Id: "1", Name: "pa h", Start: 3},
+				},
+				Message: "Kappa hi",
+			},
+			expected: []ChatFragment{
+				{EmoteId: "25", Text: "Kappa", Type: ChatFragmentTypeEmote, UrlTemplate: emoteUrlTemplate},
+				{Text: " hi", Type: ChatFragmentTypeText},
+			},
+		},
+		"emote out of range": {
+			message: &ChatPrivateMessage{
+				Emotes:  []EmoteRange{{End: 20, Id: "25", Name: "Kappa", Start: 3}},
+				Message: "hi Kappa",
+			},
+			expected: []ChatFragment{
+				{Text: "hi Kappa", Type: ChatFragmentTypeText},
+			},
+		},
+		"mention with punctuation": {
+			message: &ChatPrivateMessage{Message: "hi @Viewer_1, welcome"},
+			expected: []ChatFragment{
+				{Text: "hi ", Type: ChatFragmentTypeText},
+				{Login: "viewer_1", Text: "@Viewer_1", Type: ChatFragmentTypeMention},
+				{Text: ", welcome", Type: ChatFragmentTypeText},
+			},
+		},
+		"lone at sign": {
+			message: &ChatPrivateMessage{Message: "meet @ noon"},
+			expected: []ChatFragment{
+				{Text: "meet @ noon", Type: ChatFragmentTypeText},
+			},
+		},
+		"cheermote with bits": {
+			message: &ChatPrivateMessage{Bits: 100, Message: "cheer100 nice"},
+			expected: []ChatFragment{
+				{Bits: 100, Prefix: "Cheer", Text: "cheer100", Type: ChatFragmentTypeCheermote},
+				{Text: " nice", Type: ChatFragmentTypeText},
+			},
+		},
+		"cheermote without bits": {
+			message: &ChatPrivateMessage{Message: "cheer100 nice"},
+			expected: []ChatFragment{
+				{Text: "cheer100 nice", Type: ChatFragmentTypeText},
+			},
+		},
+		"cheermote without amount": {
+			message: &ChatPrivateMessage{Bits: 100, Message: "Cheer Cheer0 Cheer-1"},
+			expected: []ChatFragment{
+				{Text: "Cheer Cheer0 Cheer-1", Type: ChatFragmentTypeText},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fragments := test.message.FragmentsWithCheermotes([]string{"Cheer"})
+			if !slices.Equal(fragments, test.expected) {
+				t.Errorf("got %+v, want %+v", fragments, test.expected)
+			}
+		})
+	}
+}
+
+func TestRenderFragmentsHTML(t *testing.T) {
+	tests := map[string]struct {
+		fragments []ChatFragment
+		expected  string
+	}{
+		"text": {
+			fragments: []ChatFragment{
+				{Text: `<script>alert("hi")</script>`, Type: ChatFragmentTypeText},
+			},
+			expected: `&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt;`,
+		},
+		"emote": {
+			fragments: []ChatFragment{
+				{EmoteId: "25", Text: `"><script>`, Type: ChatFragmentTypeEmote, UrlTemplate: emoteUrlTemplate},
+			},
+			expected: `<img class="emote" src="https://static-cdn.jtvnw.net/emoticons/v2/25/default/dark/1.0" alt="&#34;&gt;&lt;script&gt;">`,
+		},
+		"mention": {
+			fragments: []ChatFragment{
+				{Login: `a"b`, Text: "@<b>", Type: ChatFragmentTypeMention},
+			},
+			expected: `<span class="mention" data-login="a&#34;b">@&lt;b&gt;</span>`,
+		},
+		"cheermote": {
+			fragments: []ChatFragment{
+				{Bits: 100, Prefix: "<Cheer>", Text: "Cheer100", Type: ChatFragmentTypeCheermote},
+			},
+			expected: `<span class="cheermote" data-prefix="&lt;Cheer&gt;" data-bits="100">Cheer100</span>`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rendered := RenderFragmentsHTML(test.fragments)
+			if rendered != test.expected {
+				t.Errorf("rendered %q, want %q", rendered, test.expected)
+			}
+		})
+	}
+}
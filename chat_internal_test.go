@@ -0,0 +1,41 @@
+package twitch
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	chat := &ChatClient{}
+	// Each failed attempt doubles the delay, with up to half of it taken off
+	// as jitter, until it reaches the max
+	for attempts, expected := range []time.Duration{
+		time.Second,
+		time.Second * 2,
+		time.Second * 4,
+		time.Second * 8,
+		time.Second * 16,
+		time.Second * 32,
+		time.Second * 64,
+		reconnectMaxDelay,
+		reconnectMaxDelay,
+	} {
+		chat.reconnectAttempts = attempts
+		for range 100 {
+			delay := chat.reconnectDelay()
+			if delay < expected/2 || delay > expected {
+				t.Fatalf("delay after %d attempts was %s, want between %s and %s", attempts, delay, expected/2, expected)
+			}
+		}
+	}
+	// Very large attempt counts must not overflow
+	chat.reconnectAttempts = 100
+	if delay := chat.reconnectDelay(); delay < reconnectMaxDelay/2 || delay > reconnectMaxDelay {
+		t.Errorf("delay after 100 attempts was %s", delay)
+	}
+	// Reconnects asked for by the server happen straight away
+	chat.reconnectRequested = true
+	if delay := chat.reconnectDelay(); delay != 0 {
+		t.Errorf("requested reconnect delay was %s, want 0", delay)
+	}
+}
@@ -0,0 +1,106 @@
+package twitch
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	ChatNoticeTypeAlreadyBanned                ChatNoticeType = "already_banned"
+	ChatNoticeTypeBadAuth                      ChatNoticeType = "bad_auth"
+	ChatNoticeTypeLoginAuthenticationFailed    ChatNoticeType = "login_authentication_failed"
+	ChatNoticeTypeMessageBanned                ChatNoticeType = "msg_banned"
+	ChatNoticeTypeMessageChannelSuspended      ChatNoticeType = "msg_channel_suspended"
+	ChatNoticeTypeMessageDuplicate             ChatNoticeType = "msg_duplicate"
+	ChatNoticeTypeMessageEmoteOnly             ChatNoticeType = "msg_emoteonly"
+	ChatNoticeTypeMessageFollowersOnly         ChatNoticeType = "msg_followersonly"
+	ChatNoticeTypeMessageRateLimit             ChatNoticeType = "msg_ratelimit"
+	ChatNoticeTypeMessageRequiresVerifiedPhone ChatNoticeType = "msg_requires_verified_phone_number"
+	ChatNoticeTypeMessageSlowMode              ChatNoticeType = "msg_slowmode"
+	ChatNoticeTypeMessageSubsOnly              ChatNoticeType = "msg_subsonly"
+	ChatNoticeTypeMessageSuspended             ChatNoticeType = "msg_suspended"
+	ChatNoticeTypeMessageTimedOut              ChatNoticeType = "msg_timedout"
+	ChatNoticeTypeMessageVerifiedEmail         ChatNoticeType = "msg_verified_email"
+	ChatNoticeTypeUnknown                      ChatNoticeType = ""
+)
+
+func (c *ChatClient) handleClearChat(parsedIrcMessage *IrcMessage) {
+	tags := parsedIrcMessage.Tags
+	clearChatMessage := &ChatClearChatMessage{
+		Channel:      parsedIrcMessage.Params[0],
+		RoomId:       tags["room-id"],
+		Tags:         tags,
+		TargetUserId: tags["target-user-id"],
+	}
+	// Without a target the whole chat has been cleared
+	if len(parsedIrcMessage.Params) > 1 {
+		clearChatMessage.TargetLogin = parsedIrcMessage.Params[1]
+		// A ban duration means this is a timeout rather than a ban
+		if _, ok := tags["ban-duration"]; ok {
+			clearChatMessage.IsTimeout = true
+			clearChatMessage.Duration = time.Duration(parseIntTag(tags, "ban-duration")) * time.Second
+		} else {
+			clearChatMessage.IsBan = true
+		}
+	}
+	// Run handlers if loaded
+	c.emit(parsedIrcMessage, clearChatMessage)
+}
+
+func (c *ChatClient) handleClearMessage(parsedIrcMessage *IrcMessage) {
+	tags := parsedIrcMessage.Tags
+	clearMessageMessage := &ChatClearMessageMessage{
+		Channel:         parsedIrcMessage.Params[0],
+		Login:           tags["login"],
+		Tags:            tags,
+		TargetMessageId: tags["target-msg-id"],
+	}
+	if len(parsedIrcMessage.Params) > 1 {
+		clearMessageMessage.Message = parsedIrcMessage.Params[1]
+	}
+	// Run handlers if loaded
+	c.emit(parsedIrcMessage, clearMessageMessage)
+}
+
+func (c *ChatClient) handleNotice(parsedIrcMessage *IrcMessage) {
+	noticeMessage := &ChatNoticeMessage{
+		Channel: parsedIrcMessage.Params[0],
+		Tags:    parsedIrcMessage.Tags,
+		Type:    ChatNoticeType(parsedIrcMessage.Tags["msg-id"]),
+	}
+	if len(parsedIrcMessage.Params) > 1 {
+		noticeMessage.Message = parsedIrcMessage.Params[1]
+	}
+	// Authentication failures are sent before tags are enabled so they have
+	// no msg-id, work out the type from the message instead
+	if noticeMessage.Type == ChatNoticeTypeUnknown {
+		switch noticeMessage.Message {
+		case "Login authentication failed":
+			noticeMessage.Type = ChatNoticeTypeLoginAuthenticationFailed
+		case "Improperly formatted auth":
+			noticeMessage.Type = ChatNoticeTypeBadAuth
+		}
+	}
+	// Suspended channels can't be joined
+	if noticeMessage.Type == ChatNoticeTypeMessageChannelSuspended {
+		c.failJoin(noticeMessage.Channel, ErrChannelSuspended)
+	}
+	// Messages that Twitch refuses to deliver are answered with a msg_ notice
+	if strings.HasPrefix(string(noticeMessage.Type), "msg_") {
+		c.resolveDelivery(noticeMessage.Channel, nil, &ChatSendError{Notice: noticeMessage})
+	}
+	// Run handlers if loaded
+	c.emit(parsedIrcMessage, noticeMessage)
+}
+
+func (c *ChatClient) OnClearChat(handler func(message *ChatClearChatMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnClearMessage(handler func(message *ChatClearMessageMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnNotice(handler func(message *ChatNoticeMessage)) {
+	On(c, handler)
+}
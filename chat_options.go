@@ -0,0 +1,143 @@
+package twitch
+
+import (
+	"crypto/tls"
+	"errors"
+	"time"
+)
+
+var (
+	ErrBlankAddress          error = errors.New("address cannot be blank")
+	ErrInvalidHandlerTimeout error = errors.New("handlerTimeout cannot be negative")
+	ErrInvalidMaxQueueSize   error = errors.New("maxQueueSize cannot be negative")
+	ErrInvalidRateLimits     error = errors.New("rateLimits must all be greater than zero")
+	ErrInvalidWorkers        error = errors.New("workers must be greater than zero")
+	ErrNilDialer             error = errors.New("dialer cannot be nil")
+	ErrNilTLSConfig          error = errors.New("tlsConfig cannot be nil")
+)
+
+type ChatClientOption func(c *ChatClient) error
+
+func WithAddress(address string) ChatClientOption {
+	return func(c *ChatClient) error {
+		if address == "" {
+			return ErrBlankAddress
+		}
+		c.address = address
+		return nil
+	}
+}
+
+func WithAnonymous() ChatClientOption {
+	return func(c *ChatClient) error {
+		c.authProvider = NewAnonymousProvider()
+		return nil
+	}
+}
+
+func WithDialer(dialer ContextDialer) ChatClientOption {
+	return func(c *ChatClient) error {
+		if dialer == nil {
+			return ErrNilDialer
+		}
+		c.dialer = dialer
+		return nil
+	}
+}
+
+func WithDuplicateBypass() ChatClientOption {
+	return func(c *ChatClient) error {
+		c.duplicateBypass = true
+		return nil
+	}
+}
+
+func WithGoroutineDispatch() ChatClientOption {
+	return func(c *ChatClient) error {
+		// Every event gets its own go routine so there is no ordering at all
+		c.dispatcher.mode = chatDispatchGoroutine
+		return nil
+	}
+}
+
+func WithHandlerTimeout(handlerTimeout time.Duration) ChatClientOption {
+	return func(c *ChatClient) error {
+		// Zero means handlers can run for as long as they like
+		if handlerTimeout < 0 {
+			return ErrInvalidHandlerTimeout
+		}
+		c.dispatcher.timeout = handlerTimeout
+		return nil
+	}
+}
+
+func WithMaxQueueSize(maxQueueSize int) ChatClientOption {
+	return func(c *ChatClient) error {
+		// Zero means the queue can grow without limit
+		if maxQueueSize < 0 {
+			return ErrInvalidMaxQueueSize
+		}
+		c.maxQueueSize = maxQueueSize
+		return nil
+	}
+}
+
+func WithMessageSplitting() ChatClientOption {
+	return func(c *ChatClient) error {
+		c.messageSplitting = true
+		return nil
+	}
+}
+
+func WithPlaintext() ChatClientOption {
+	return func(c *ChatClient) error {
+		c.plaintext = true
+		return nil
+	}
+}
+
+func WithRateLimits(rateLimits ChatRateLimits) ChatClientOption {
+	return func(c *ChatClient) error {
+		if rateLimits.JoinLimit <= 0 ||
+			rateLimits.JoinPeriod <= 0 ||
+			rateLimits.MessageLimit <= 0 ||
+			rateLimits.MessagePeriod <= 0 ||
+			rateLimits.ModeratorMessageLimit <= 0 {
+			return ErrInvalidRateLimits
+		}
+		c.rateLimits = rateLimits
+		return nil
+	}
+}
+
+func WithTLSConfig(tlsConfig *tls.Config) ChatClientOption {
+	return func(c *ChatClient) error {
+		if tlsConfig == nil {
+			return ErrNilTLSConfig
+		}
+		c.tlsConfig = tlsConfig
+		return nil
+	}
+}
+
+func WithWebSocket() ChatClientOption {
+	return func(c *ChatClient) error {
+		// Keep any address that has already been set
+		if c.address == serverAddress {
+			c.address = websocketServerAddress
+		}
+		c.websocket = true
+		return nil
+	}
+}
+
+func WithWorkerPoolDispatch(workers int) ChatClientOption {
+	return func(c *ChatClient) error {
+		if workers <= 0 {
+			return ErrInvalidWorkers
+		}
+		c.dispatcher.mode = chatDispatchWorkerPool
+		c.dispatcher.workers = workers
+		return nil
+	}
+}
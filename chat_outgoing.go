@@ -0,0 +1,96 @@
+package twitch
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	duplicateBypassSuffix string = " \U000E0000"
+	maxChatMessageLength  int    = 500
+)
+
+func (c *ChatClient) bypassDuplicate(outgoing *chatOutgoingMessage) {
+	if !c.duplicateBypass || outgoing.message.Command != "PRIVMSG" {
+		return
+	}
+	channel, message := outgoing.message.Params[0], outgoing.message.Params[1]
+	c.lastMessagesMutex.Lock()
+	defer c.lastMessagesMutex.Unlock()
+	// Twitch drops a message that is the same as the last one sent to the
+	// channel, an invisible character is enough to make it different. This is
+	// done as the message is written so that only messages actually sent count
+	if c.lastMessages[channel] == message && utf8.RuneCountInString(message+duplicateBypassSuffix) <= maxChatMessageLength {
+		bypassed := *outgoing.message
+		bypassed.Params = []string{channel, message + duplicateBypassSuffix}
+		line, err := bypassed.Marshal()
+		if err == nil {
+			outgoing.line = line + "\r\n"
+			message = bypassed.Params[1]
+		}
+	}
+	c.lastMessages[channel] = message
+}
+
+func (c *ChatClient) prepareChatMessage(message string) []string {
+	message = sanitizeChatMessage(message)
+	if !c.messageSplitting {
+		return []string{message}
+	}
+	maxLength := maxChatMessageLength
+	// Leave room for the suffix in case a part needs it
+	if c.duplicateBypass {
+		maxLength -= utf8.RuneCountInString(duplicateBypassSuffix)
+	}
+	return splitChatMessage(message, maxLength)
+}
+
+func splitChatMessage(message string, maxLength int) []string {
+	if utf8.RuneCountInString(message) <= maxLength {
+		return []string{message}
+	}
+	// The part numbers take up room as well so keep going until the number of
+	// parts is enough to hold the message with them added
+	count := 2
+	for {
+		prefixLength := len(fmt.Sprintf("(%d/%d) ", count, count))
+		chunks := splitChatMessageWords(message, maxLength-prefixLength)
+		if len(chunks) <= count {
+			for index, chunk := range chunks {
+				chunks[index] = fmt.Sprintf("(%d/%d) %s", index+1, len(chunks), chunk)
+			}
+			return chunks
+		}
+		count = len(chunks)
+	}
+}
+
+func splitChatMessageWords(message string, maxLength int) []string {
+	chunks := []string{}
+	chunk := []rune{}
+	for _, word := range strings.Fields(message) {
+		wordRunes := []rune(word)
+		// Keep adding words to the chunk until the next one won't fit
+		if len(chunk) > 0 {
+			if len(chunk)+1+len(wordRunes) <= maxLength {
+				chunk = append(chunk, ' ')
+				chunk = append(chunk, wordRunes...)
+				continue
+			}
+			chunks = append(chunks, string(chunk))
+			chunk = nil
+		}
+		// Words longer than a whole chunk have to be broken up, this is done
+		// on rune boundaries so characters are never cut in half
+		for len(wordRunes) > maxLength {
+			chunks = append(chunks, string(wordRunes[:maxLength]))
+			wordRunes = wordRunes[maxLength:]
+		}
+		chunk = wordRunes
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, string(chunk))
+	}
+	return chunks
+}
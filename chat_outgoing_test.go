@@ -0,0 +1,112 @@
+package twitch
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func newTestOutgoingMessage(t *testing.T, channel string, message string) *chatOutgoingMessage {
+	t.Helper()
+	ircMessage := &IrcMessage{
+		Command: "PRIVMSG",
+		Params:  []string{channel, message},
+	}
+	line, err := ircMessage.Marshal()
+	if err != nil {
+		t.Fatalf("unable to marshal: %s", err)
+	}
+	return &chatOutgoingMessage{
+		line:    line + "\r\n",
+		message: ircMessage,
+	}
+}
+
+func sentChatMessage(t *testing.T, outgoing *chatOutgoingMessage) string {
+	t.Helper()
+	parsed, err := ParseIrcMessage(outgoing.line)
+	if err != nil {
+		t.Fatalf("unable to parse %q: %s", outgoing.line, err)
+	}
+	return parsed.Params[1]
+}
+
+func TestBypassDuplicate(t *testing.T) {
+	chat, err := NewChatClient(NewAnonymousProvider(), WithDuplicateBypass())
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	sent := []string{}
+	for _, message := range []string{"hello", "hello", "hello", "world", "hello"} {
+		outgoing := newTestOutgoingMessage(t, "#channel", message)
+		chat.bypassDuplicate(outgoing)
+		sent = append(sent, sentChatMessage(t, outgoing))
+	}
+	expected := []string{"hello", "hello" + duplicateBypassSuffix, "hello", "world", "hello"}
+	for index := range expected {
+		if sent[index] != expected[index] {
+			t.Errorf("message %d was %q, want %q", index, sent[index], expected[index])
+		}
+	}
+	// Other channels have their own last message
+	outgoing := newTestOutgoingMessage(t, "#other", "hello")
+	chat.bypassDuplicate(outgoing)
+	if message := sentChatMessage(t, outgoing); message != "hello" {
+		t.Errorf("message to another channel was %q, want %q", message, "hello")
+	}
+}
+
+func TestBypassDuplicateMaxLength(t *testing.T) {
+	chat, err := NewChatClient(NewAnonymousProvider(), WithDuplicateBypass())
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	message := strings.Repeat("a", maxChatMessageLength)
+	for range 2 {
+		outgoing := newTestOutgoingMessage(t, "#channel", message)
+		chat.bypassDuplicate(outgoing)
+		// Going over the limit would get the message rejected for its length
+		// instead of being a duplicate
+		if length := utf8.RuneCountInString(sentChatMessage(t, outgoing)); length > maxChatMessageLength {
+			t.Errorf("sent %d characters, want at most %d", length, maxChatMessageLength)
+		}
+	}
+}
+
+func TestBypassDuplicateOnlySentMessages(t *testing.T) {
+	chat, err := NewChatClient(NewAnonymousProvider(), WithDuplicateBypass())
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	// A message that is prepared but never written doesn't count as sent
+	for range 2 {
+		chat.prepareChatMessage("hello")
+	}
+	outgoing := newTestOutgoingMessage(t, "#channel", "hello")
+	chat.bypassDuplicate(outgoing)
+	if message := sentChatMessage(t, outgoing); message != "hello" {
+		t.Errorf("message was %q, want %q", message, "hello")
+	}
+}
+
+func TestPrepareChatMessageSplitting(t *testing.T) {
+	chat, err := NewChatClient(NewAnonymousProvider(), WithDuplicateBypass(), WithMessageSplitting())
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	message := strings.Repeat("word ", 250) + strings.Repeat("é", 600)
+	parts := chat.prepareChatMessage(message)
+	if len(parts) < 2 {
+		t.Fatalf("got %d parts, want more than 1", len(parts))
+	}
+	// Every part has room left for the suffix
+	maxLength := maxChatMessageLength - utf8.RuneCountInString(duplicateBypassSuffix)
+	for index, part := range parts {
+		if !utf8.ValidString(part) {
+			t.Errorf("part %d is not valid utf8", index)
+		}
+		if length := utf8.RuneCountInString(part); length > maxLength {
+			t.Errorf("part %d has %d characters, want at most %d", index, length, maxLength)
+		}
+	}
+}
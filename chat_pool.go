@@ -0,0 +1,351 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+var (
+	ErrInvalidConnections              error = errors.New("connections must be greater than zero")
+	ErrInvalidMaxChannelsPerConnection error = errors.New("maxChannelsPerConnection must be greater than zero")
+	ErrPoolFull                        error = errors.New("every connection in the pool is full")
+)
+
+type ChatPool struct {
+	channelOwners            map[string]*ChatClient
+	clients                  []*ChatClient
+	connected                map[*ChatClient]bool
+	maxChannelsPerConnection int
+	mutex                    sync.Mutex
+}
+
+func (p *ChatPool) channelCount(client *ChatClient) int {
+	count := 0
+	for _, owner := range p.channelOwners {
+		if owner == client {
+			count++
+		}
+	}
+	return count
+}
+
+func (p *ChatPool) Channels() []string {
+	var channels []string
+	for _, client := range p.clients {
+		channels = append(channels, client.Channels()...)
+	}
+	slices.Sort(channels)
+	return channels
+}
+
+func (p *ChatPool) handleDisconnect(client *ChatClient) {
+	p.mutex.Lock()
+	p.connected[client] = false
+	// Move the channels to connections that are still up
+	moves := make(map[*ChatClient][]string)
+	var moved []string
+	for channel, owner := range p.channelOwners {
+		if owner != client {
+			continue
+		}
+		target := p.leastLoaded(client, true)
+		if target == nil {
+			// Nowhere to go, it will be rejoined when the connection is back
+			continue
+		}
+		p.channelOwners[channel] = target
+		moves[target] = append(moves[target], channel)
+		moved = append(moved, channel)
+	}
+	p.mutex.Unlock()
+	if len(moved) == 0 {
+		return
+	}
+	log.Printf("Moving %d channels to other connections", len(moved))
+	for _, channel := range moved {
+		client.Part(channel)
+	}
+	for target, channels := range moves {
+		err := target.JoinMany(channels...)
+		if err != nil {
+			log.Printf("Unable to move channels: %s", err)
+		}
+	}
+}
+
+func (p *ChatPool) Join(channel string) error {
+	return p.JoinMany(channel)
+}
+
+func (p *ChatPool) JoinMany(channels ...string) error {
+	// Make sure every channel is valid before joining any of them
+	normalizedChannels := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		normalizedChannel, err := normalizeChannel(channel)
+		if err != nil {
+			return err
+		}
+		normalizedChannels = append(normalizedChannels, normalizedChannel)
+	}
+	// Give each channel to the connection with the fewest channels
+	p.mutex.Lock()
+	joins := make(map[*ChatClient][]string)
+	for _, channel := range normalizedChannels {
+		if _, ok := p.channelOwners[channel]; ok {
+			continue
+		}
+		owner := p.leastLoaded(nil, false)
+		if owner == nil {
+			// Undo what has been assigned so none of the channels are joined
+			for _, ownerChannels := range joins {
+				for _, ownerChannel := range ownerChannels {
+					delete(p.channelOwners, ownerChannel)
+				}
+			}
+			p.mutex.Unlock()
+			return ErrPoolFull
+		}
+		p.channelOwners[channel] = owner
+		joins[owner] = append(joins[owner], channel)
+	}
+	p.mutex.Unlock()
+	for owner, ownerChannels := range joins {
+		err := owner.JoinMany(ownerChannels...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ChatPool) leastLoaded(exclude *ChatClient, connectedOnly bool) *ChatClient {
+	var leastLoaded *ChatClient
+	leastLoadedCount := p.maxChannelsPerConnection
+	for _, client := range p.clients {
+		if client == exclude || (connectedOnly && !p.connected[client]) {
+			continue
+		}
+		count := p.channelCount(client)
+		if count < leastLoadedCount {
+			leastLoaded = client
+			leastLoadedCount = count
+		}
+	}
+	return leastLoaded
+}
+
+func (p *ChatPool) OnAny(handler func(event Event)) func() {
+	unsubscribes := make([]func(), 0, len(p.clients))
+	p.OnClient(func(client *ChatClient) {
+		unsubscribes = append(unsubscribes, client.OnAny(handler))
+	})
+	return joinUnsubscribes(unsubscribes)
+}
+
+func (p *ChatPool) OnClearChat(handler func(message *ChatClearChatMessage)) {
+	p.OnClient(func(client *ChatClient) {
+		client.OnClearChat(handler)
+	})
+}
+
+func (p *ChatPool) OnClearMessage(handler func(message *ChatClearMessageMessage)) {
+	p.OnClient(func(client *ChatClient) {
+		client.OnClearMessage(handler)
+	})
+}
+
+func (p *ChatPool) OnClient(setup func(client *ChatClient)) {
+	// Each connection runs its handlers in its own go routine so handlers
+	// added through the pool can be called concurrently
+	for _, client := range p.clients {
+		setup(client)
+	}
+}
+
+func (p *ChatPool) OnError(handler func(err error, raw string)) {
+	p.OnClient(func(client *ChatClient) {
+		client.OnError(handler)
+	})
+}
+
+func (p *ChatPool) OnJoin(handler func(message *ChatJoinMessage)) {
+	p.OnClient(func(client *ChatClient) {
+		client.OnJoin(handler)
+	})
+}
+
+func (p *ChatPool) OnNotice(handler func(message *ChatNoticeMessage)) {
+	p.OnClient(func(client *ChatClient) {
+		client.OnNotice(handler)
+	})
+}
+
+func (p *ChatPool) OnPart(handler func(message *ChatPartMessage)) {
+	p.OnClient(func(client *ChatClient) {
+		client.OnPart(handler)
+	})
+}
+
+func (p *ChatPool) OnPrivateMessage(handler func(message *ChatPrivateMessage)) {
+	p.OnClient(func(client *ChatClient) {
+		client.OnPrivateMessage(handler)
+	})
+}
+
+func (p *ChatPool) OnRaw(handler func(message *IrcMessage)) func() {
+	unsubscribes := make([]func(), 0, len(p.clients))
+	p.OnClient(func(client *ChatClient) {
+		unsubscribes = append(unsubscribes, client.OnRaw(handler))
+	})
+	return joinUnsubscribes(unsubscribes)
+}
+
+func (p *ChatPool) OnRoomState(handler func(message *ChatRoomStateMessage)) {
+	p.OnClient(func(client *ChatClient) {
+		client.OnRoomState(handler)
+	})
+}
+
+func (p *ChatPool) OnUserNotice(handler func(message *ChatUserNoticeMessage)) {
+	p.OnClient(func(client *ChatClient) {
+		client.OnUserNotice(handler)
+	})
+}
+
+func (p *ChatPool) OnUserState(handler func(message *ChatUserStateMessage)) {
+	p.OnClient(func(client *ChatClient) {
+		client.OnUserState(handler)
+	})
+}
+
+func (p *ChatPool) owner(channel string) (*ChatClient, error) {
+	channel, err := normalizeChannel(channel)
+	if err != nil {
+		return nil, err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	owner, ok := p.channelOwners[channel]
+	if !ok {
+		return nil, ErrNotInChannel
+	}
+	return owner, nil
+}
+
+func (p *ChatPool) Part(channel string) error {
+	owner, err := p.owner(channel)
+	if err != nil {
+		return err
+	}
+	channel, _ = normalizeChannel(channel)
+	p.mutex.Lock()
+	delete(p.channelOwners, channel)
+	p.mutex.Unlock()
+	return owner.Part(channel)
+}
+
+func (p *ChatPool) Reply(message *ChatPrivateMessage, response string) error {
+	owner, err := p.owner(message.Channel)
+	if err != nil {
+		return err
+	}
+	return owner.Reply(message, response)
+}
+
+func (p *ChatPool) ReplyWithResult(ctx context.Context, message *ChatPrivateMessage, response string) (*ChatSendResult, error) {
+	owner, err := p.owner(message.Channel)
+	if err != nil {
+		return nil, err
+	}
+	return owner.ReplyWithResult(ctx, message, response)
+}
+
+func (p *ChatPool) Run(ctx context.Context) error {
+	log.Printf("Starting chat pool with %d connections", len(p.clients))
+	wg := &sync.WaitGroup{}
+	wg.Add(len(p.clients))
+	for _, client := range p.clients {
+		go func() {
+			defer wg.Done()
+			client.Run(ctx)
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (p *ChatPool) Say(channel string, message string) error {
+	owner, err := p.owner(channel)
+	if err != nil {
+		return err
+	}
+	return owner.Say(channel, message)
+}
+
+func (p *ChatPool) SayWithResult(ctx context.Context, channel string, message string) (*ChatSendResult, error) {
+	owner, err := p.owner(channel)
+	if err != nil {
+		return nil, err
+	}
+	return owner.SayWithResult(ctx, channel, message)
+}
+
+func (p *ChatPool) subscribe(eventType reflect.Type, handler func(ctx context.Context, event Event)) func() {
+	unsubscribes := make([]func(), 0, len(p.clients))
+	p.OnClient(func(client *ChatClient) {
+		unsubscribes = append(unsubscribes, client.subscribe(eventType, handler))
+	})
+	return joinUnsubscribes(unsubscribes)
+}
+
+func joinUnsubscribes(unsubscribes []func()) func() {
+	return func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
+func NewChatPool(
+	authProvider AuthProvider,
+	connections int,
+	maxChannelsPerConnection int,
+	options ...ChatClientOption,
+) (*ChatPool, error) {
+	if connections <= 0 {
+		return nil, ErrInvalidConnections
+	}
+	if maxChannelsPerConnection <= 0 {
+		return nil, ErrInvalidMaxChannelsPerConnection
+	}
+	pool := &ChatPool{
+		channelOwners:            make(map[string]*ChatClient),
+		connected:                make(map[*ChatClient]bool),
+		maxChannelsPerConnection: maxChannelsPerConnection,
+	}
+	// Create the connections
+	for range connections {
+		client, err := NewChatClient(authProvider, options...)
+		if err != nil {
+			return nil, err
+		}
+		client.OnConnect(func(message *ChatConnectMessage) {
+			pool.mutex.Lock()
+			pool.connected[client] = true
+			pool.mutex.Unlock()
+		})
+		client.OnDisconnect(func(message *ChatDisconnectMessage) {
+			// Nothing to move if the whole pool is shutting down
+			if errors.Is(message.Error, context.Canceled) || errors.Is(message.Error, context.DeadlineExceeded) {
+				return
+			}
+			pool.handleDisconnect(client)
+		})
+		pool.clients = append(pool.clients, client)
+	}
+	return pool, nil
+}
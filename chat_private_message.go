@@ -0,0 +1,130 @@
+package twitch
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (m *ChatPrivateMessage) hasBadge(names ...string) bool {
+	for _, badge := range m.Badges {
+		if slices.Contains(names, badge.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *ChatPrivateMessage) IsBroadcaster() bool {
+	return m.hasBadge("broadcaster")
+}
+
+func (m *ChatPrivateMessage) IsModerator() bool {
+	return m.Tags["mod"] == "1" || m.hasBadge("moderator")
+}
+
+func (m *ChatPrivateMessage) IsSubscriber() bool {
+	return m.Tags["subscriber"] == "1" || m.hasBadge("subscriber", "founder")
+}
+
+func (m *ChatPrivateMessage) IsVip() bool {
+	return m.Tags["vip"] == "1" || m.hasBadge("vip")
+}
+
+func newChatPrivateMessage(parsedIrcMessage *IrcMessage) *ChatPrivateMessage {
+	tags := parsedIrcMessage.Tags
+	privateMessage := &ChatPrivateMessage{
+		Badges:             parseBadgesTag(tags["badges"]),
+		Bits:               parseIntTag(tags, "bits"),
+		Channel:            parsedIrcMessage.Params[0],
+		Color:              tags["color"],
+		DisplayName:        tags["display-name"],
+		Id:                 tags["id"],
+		IsFirstMessage:     tags["first-msg"] == "1",
+		IsReturningChatter: tags["returning-chatter"] == "1",
+		Message:            parsedIrcMessage.Params[1],
+		RoomId:             tags["room-id"],
+		SubscriberMonths:   parseSubscriberMonths(tags["badge-info"]),
+		Tags:               tags,
+		UserId:             tags["user-id"],
+		Username:           parsedIrcMessage.Source.Username,
+	}
+	privateMessage.Emotes = parseEmotesTag(tags["emotes"], privateMessage.Message)
+	// Time the message was received by Twitch in milliseconds
+	sentAt, err := strconv.ParseInt(tags["tmi-sent-ts"], 10, 64)
+	if err == nil {
+		privateMessage.SentAt = time.UnixMilli(sentAt)
+	}
+	// Messages sent as a reply include the message they are replying to
+	if tags["reply-parent-msg-id"] != "" {
+		privateMessage.ReplyParent = &ReplyParent{
+			DisplayName:     tags["reply-parent-display-name"],
+			Login:           tags["reply-parent-user-login"],
+			Message:         tags["reply-parent-msg-body"],
+			MessageId:       tags["reply-parent-msg-id"],
+			ThreadLogin:     tags["reply-thread-parent-user-login"],
+			ThreadMessageId: tags["reply-thread-parent-msg-id"],
+			UserId:          tags["reply-parent-user-id"],
+		}
+	}
+	return privateMessage
+}
+
+func parseEmotesTag(rawEmotes string, message string) []EmoteRange {
+	if rawEmotes == "" {
+		return nil
+	}
+	// Twitch gives the positions in characters not bytes
+	messageRunes := []rune(message)
+	var emotes []EmoteRange
+	for _, rawEmote := range strings.Split(rawEmotes, "/") {
+		emoteId, rawPositions, found := strings.Cut(rawEmote, ":")
+		if !found {
+			continue
+		}
+		for _, rawPosition := range strings.Split(rawPositions, ",") {
+			rawStart, rawEnd, found := strings.Cut(rawPosition, "-")
+			if !found {
+				continue
+			}
+			start, err := strconv.Atoi(rawStart)
+			if err != nil {
+				continue
+			}
+			end, err := strconv.Atoi(rawEnd)
+			if err != nil {
+				continue
+			}
+			// The end position from Twitch is inclusive, we store it exclusive
+			end++
+			if start < 0 || start >= end || end > len(messageRunes) {
+				continue
+			}
+			emotes = append(emotes, EmoteRange{
+				End:   end,
+				Id:    emoteId,
+				Name:  string(messageRunes[start:end]),
+				Start: start,
+			})
+		}
+	}
+	// Keep the emotes in the order they appear in the message
+	slices.SortFunc(emotes, func(a EmoteRange, b EmoteRange) int {
+		return a.Start - b.Start
+	})
+	return emotes
+}
+
+func parseSubscriberMonths(rawBadgeInfo string) int {
+	for _, badge := range parseBadgesTag(rawBadgeInfo) {
+		if badge.Name == "subscriber" || badge.Name == "founder" {
+			months, err := strconv.Atoi(badge.Version)
+			if err != nil {
+				return 0
+			}
+			return months
+		}
+	}
+	return 0
+}
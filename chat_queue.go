@@ -0,0 +1,91 @@
+package twitch
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSendQueueFull error = errors.New("send queue is full")
+)
+
+type chatOutgoingMessage struct {
+	delivery *chatDelivery
+	line     string
+	message  *IrcMessage
+}
+
+type chatOutgoingQueue struct {
+	limited     []*chatOutgoingMessage
+	maxSize     int
+	mutex       sync.Mutex
+	notify      chan bool
+	rateLimiter *chatRateLimiter
+	unlimited   []*chatOutgoingMessage
+}
+
+func (q *chatOutgoingQueue) clear() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	cleared := len(q.limited) + len(q.unlimited)
+	// Anyone waiting on a dropped message needs to know it was never sent
+	for _, outgoing := range q.limited {
+		if outgoing.delivery != nil {
+			outgoing.delivery.resolve(nil, ErrMessageDropped)
+		}
+	}
+	q.limited = nil
+	q.unlimited = nil
+	return cleared
+}
+
+func (q *chatOutgoingQueue) pop(now time.Time) (*chatOutgoingMessage, time.Duration) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	// Protocol messages such as PONG are never held up by the rate limits
+	if len(q.unlimited) > 0 {
+		outgoing := q.unlimited[0]
+		q.unlimited = q.unlimited[1:]
+		return outgoing, 0
+	}
+	if len(q.limited) == 0 {
+		return nil, 0
+	}
+	// Only send the next limited message if it fits within the rate limits
+	outgoing := q.limited[0]
+	wait := q.rateLimiter.wait(now, outgoing.message)
+	if wait > 0 {
+		return nil, wait
+	}
+	q.rateLimiter.take(now, outgoing.message)
+	q.limited = q.limited[1:]
+	return outgoing, 0
+}
+
+func (q *chatOutgoingQueue) push(outgoing *chatOutgoingMessage) error {
+	q.mutex.Lock()
+	if q.rateLimiter.isLimited(outgoing.message) {
+		if q.maxSize > 0 && len(q.limited) >= q.maxSize {
+			q.mutex.Unlock()
+			return ErrSendQueueFull
+		}
+		q.limited = append(q.limited, outgoing)
+	} else {
+		q.unlimited = append(q.unlimited, outgoing)
+	}
+	q.mutex.Unlock()
+	// Wake up the writer if it is waiting
+	select {
+	case q.notify <- true:
+	default:
+	}
+	return nil
+}
+
+func newChatOutgoingQueue(rateLimiter *chatRateLimiter) *chatOutgoingQueue {
+	return &chatOutgoingQueue{
+		notify:      make(chan bool, 1),
+		rateLimiter: rateLimiter,
+	}
+}
@@ -0,0 +1,80 @@
+package twitch
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChatOutgoingQueuePop(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	queue := newChatOutgoingQueue(newChatRateLimiter(ChatRateLimits{
+		JoinLimit:             1,
+		JoinPeriod:            time.Second * 10,
+		MessageLimit:          1,
+		MessagePeriod:         time.Second * 30,
+		ModeratorMessageLimit: 1,
+	}))
+	for _, message := range []*IrcMessage{
+		newTestPrivateMessage("#channel"),
+		newTestPrivateMessage("#channel"),
+		{Command: "PONG", Params: []string{"tmi.twitch.tv"}},
+	} {
+		err := queue.push(&chatOutgoingMessage{
+			message: message,
+		})
+		if err != nil {
+			t.Fatalf("unable to push %s: %s", message.Command, err)
+		}
+	}
+	// Protocol messages skip ahead of anything being held by the limits
+	outgoing, _ := queue.pop(now)
+	if outgoing == nil || outgoing.message.Command != "PONG" {
+		t.Fatalf("popped %+v first, want PONG", outgoing)
+	}
+	outgoing, _ = queue.pop(now)
+	if outgoing == nil || outgoing.message.Command != "PRIVMSG" {
+		t.Fatalf("popped %+v second, want PRIVMSG", outgoing)
+	}
+	outgoing, wait := queue.pop(now)
+	if outgoing != nil || wait != time.Second*30 {
+		t.Fatalf("popped %+v with a wait of %s, want nothing for %s", outgoing, wait, time.Second*30)
+	}
+	outgoing, _ = queue.pop(now.Add(wait))
+	if outgoing == nil {
+		t.Fatal("nothing popped once the limit reset")
+	}
+}
+
+func TestChatOutgoingQueueOverflow(t *testing.T) {
+	chat, err := NewChatClient(NewAnonymousProvider(), WithMaxQueueSize(2))
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	overflows := []*ChatQueueOverflowMessage{}
+	chat.OnQueueOverflow(func(message *ChatQueueOverflowMessage) {
+		overflows = append(overflows, message)
+	})
+	for index := range 2 {
+		err = chat.send(newTestPrivateMessage("#channel"))
+		if err != nil {
+			t.Fatalf("message %d was not queued: %s", index, err)
+		}
+	}
+	overflowing := newTestPrivateMessage("#channel")
+	err = chat.send(overflowing)
+	if !errors.Is(err, ErrSendQueueFull) {
+		t.Errorf("got error %v, want %v", err, ErrSendQueueFull)
+	}
+	if len(overflows) != 1 || overflows[0].Message != overflowing {
+		t.Errorf("got overflows %+v, want one for the dropped message", overflows)
+	}
+	// Messages that aren't rate limited are never dropped
+	err = chat.send(&IrcMessage{Command: "PONG", Params: []string{"tmi.twitch.tv"}})
+	if err != nil {
+		t.Errorf("unable to queue PONG: %s", err)
+	}
+	if dropped := chat.outgoingQueue.clear(); dropped != 3 {
+		t.Errorf("cleared %d messages, want 3", dropped)
+	}
+}
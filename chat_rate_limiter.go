@@ -0,0 +1,134 @@
+package twitch
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ChatRateLimitsDefault ChatRateLimits = ChatRateLimits{
+		JoinLimit:             20,
+		JoinPeriod:            time.Second * 10,
+		MessageLimit:          20,
+		MessagePeriod:         time.Second * 30,
+		ModeratorMessageLimit: 100,
+	}
+	ChatRateLimitsVerified ChatRateLimits = ChatRateLimits{
+		JoinLimit:             2000,
+		JoinPeriod:            time.Second * 10,
+		MessageLimit:          7500,
+		MessagePeriod:         time.Second * 30,
+		ModeratorMessageLimit: 7500,
+	}
+)
+
+type chatRateLimiter struct {
+	joinBucket             *rateLimitBucket
+	messageBucket          *rateLimitBucket
+	moderatorChannels      map[string]bool
+	moderatorMessageBucket *rateLimitBucket
+	mutex                  sync.Mutex
+}
+
+func (l *chatRateLimiter) isModerator(channel string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.moderatorChannels[channel]
+}
+
+func (l *chatRateLimiter) isLimited(message *IrcMessage) bool {
+	return message.Command == "JOIN" || message.Command == "PRIVMSG"
+}
+
+func (l *chatRateLimiter) joinTokens(message *IrcMessage) int {
+	// Each channel in a comma separated JOIN counts as a join
+	return len(strings.Split(message.Params[0], ","))
+}
+
+func (l *chatRateLimiter) setModerator(channel string, isModerator bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if isModerator {
+		l.moderatorChannels[channel] = true
+	} else {
+		delete(l.moderatorChannels, channel)
+	}
+}
+
+func (l *chatRateLimiter) take(now time.Time, message *IrcMessage) {
+	switch message.Command {
+	case "JOIN":
+		l.joinBucket.take(now, l.joinTokens(message))
+	case "PRIVMSG":
+		// Every message counts towards the moderator limit, messages in
+		// channels where we are not a moderator also count to the lower limit
+		l.moderatorMessageBucket.take(now, 1)
+		if !l.isModerator(message.Params[0]) {
+			l.messageBucket.take(now, 1)
+		}
+	}
+}
+
+func (l *chatRateLimiter) wait(now time.Time, message *IrcMessage) time.Duration {
+	switch message.Command {
+	case "JOIN":
+		return l.joinBucket.wait(now, l.joinTokens(message))
+	case "PRIVMSG":
+		wait := l.moderatorMessageBucket.wait(now, 1)
+		if !l.isModerator(message.Params[0]) {
+			wait = max(wait, l.messageBucket.wait(now, 1))
+		}
+		return wait
+	}
+	return 0
+}
+
+type rateLimitBucket struct {
+	limit  int
+	period time.Duration
+	used   []time.Time
+}
+
+func (b *rateLimitBucket) expire(now time.Time) {
+	// A token is returned to the bucket a full period after it was used, this
+	// matches how Twitch counts messages and never allows a burst over the limit
+	expired := 0
+	for expired < len(b.used) && !now.Before(b.used[expired].Add(b.period)) {
+		expired++
+	}
+	b.used = b.used[expired:]
+}
+
+func (b *rateLimitBucket) take(now time.Time, tokens int) {
+	for range min(tokens, b.limit) {
+		b.used = append(b.used, now)
+	}
+}
+
+func (b *rateLimitBucket) wait(now time.Time, tokens int) time.Duration {
+	b.expire(now)
+	tokens = min(tokens, b.limit)
+	available := b.limit - len(b.used)
+	if available >= tokens {
+		return 0
+	}
+	// Wait for enough of the oldest tokens to be returned
+	return b.used[tokens-available-1].Add(b.period).Sub(now)
+}
+
+func newChatRateLimiter(limits ChatRateLimits) *chatRateLimiter {
+	return &chatRateLimiter{
+		joinBucket:             newRateLimitBucket(limits.JoinLimit, limits.JoinPeriod),
+		messageBucket:          newRateLimitBucket(limits.MessageLimit, limits.MessagePeriod),
+		moderatorChannels:      make(map[string]bool),
+		moderatorMessageBucket: newRateLimitBucket(limits.ModeratorMessageLimit, limits.MessagePeriod),
+	}
+}
+
+func newRateLimitBucket(limit int, period time.Duration) *rateLimitBucket {
+	return &rateLimitBucket{
+		limit:  limit,
+		period: period,
+	}
+}
@@ -0,0 +1,159 @@
+package twitch
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestPrivateMessage(channel string) *IrcMessage {
+	return &IrcMessage{
+		Command: "PRIVMSG",
+		Params:  []string{channel, "hello"},
+	}
+}
+
+func TestRateLimitBucket(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket := newRateLimitBucket(20, time.Second*30)
+	for index := range 20 {
+		if wait := bucket.wait(now, 1); wait != 0 {
+			t.Fatalf("token %d had to wait %s", index, wait)
+		}
+		bucket.take(now, 1)
+		now = now.Add(time.Second)
+	}
+	// The first token was taken 20 seconds ago so it comes back in 10
+	if wait := bucket.wait(now, 1); wait != time.Second*10 {
+		t.Errorf("waited %s, want %s", wait, time.Second*10)
+	}
+	if wait := bucket.wait(now.Add(time.Second*10), 1); wait != 0 {
+		t.Errorf("waited %s once the token was returned, want 0", wait)
+	}
+	// Three tokens need the three oldest to be returned
+	if wait := bucket.wait(now, 3); wait != time.Second*12 {
+		t.Errorf("waited %s for 3 tokens, want %s", wait, time.Second*12)
+	}
+}
+
+func TestRateLimitBucketNeverBursts(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket := newRateLimitBucket(20, time.Second*30)
+	// Send as fast as the bucket allows for two minutes and make sure no 30
+	// second window ever holds more than the limit
+	sent := []time.Time{}
+	end := now.Add(time.Minute * 2)
+	for now.Before(end) {
+		wait := bucket.wait(now, 1)
+		if wait > 0 {
+			now = now.Add(wait)
+			continue
+		}
+		bucket.take(now, 1)
+		sent = append(sent, now)
+	}
+	for index := range sent {
+		inWindow := 0
+		for _, other := range sent[index:] {
+			if other.Sub(sent[index]) < time.Second*30 {
+				inWindow++
+			}
+		}
+		if inWindow > 20 {
+			t.Fatalf("sent %d messages in 30 seconds starting at %s", inWindow, sent[index])
+		}
+	}
+	if len(sent) != 80 {
+		t.Errorf("sent %d messages in two minutes, want 80", len(sent))
+	}
+}
+
+func TestChatRateLimiterMessages(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newChatRateLimiter(ChatRateLimitsDefault)
+	for range 20 {
+		limiter.take(now, newTestPrivateMessage("#channel"))
+	}
+	if wait := limiter.wait(now, newTestPrivateMessage("#channel")); wait != time.Second*30 {
+		t.Errorf("waited %s after 20 messages, want %s", wait, time.Second*30)
+	}
+	// Channels we moderate only use the higher limit
+	limiter.setModerator("#moderated", true)
+	if wait := limiter.wait(now, newTestPrivateMessage("#moderated")); wait != 0 {
+		t.Errorf("waited %s in a moderated channel, want 0", wait)
+	}
+	for range 80 {
+		limiter.take(now, newTestPrivateMessage("#moderated"))
+	}
+	if wait := limiter.wait(now, newTestPrivateMessage("#moderated")); wait != time.Second*30 {
+		t.Errorf("waited %s after 100 messages, want %s", wait, time.Second*30)
+	}
+	// Losing moderator goes back to the lower limit
+	limiter.setModerator("#moderated", false)
+	if limiter.isModerator("#moderated") {
+		t.Error("still a moderator after being removed")
+	}
+	if wait := limiter.wait(now, newTestPrivateMessage("#moderated")); wait != time.Second*30 {
+		t.Errorf("waited %s after losing moderator, want %s", wait, time.Second*30)
+	}
+}
+
+func TestChatRateLimiterModeratorMessagesCountTowardsTotal(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newChatRateLimiter(ChatRateLimitsDefault)
+	limiter.setModerator("#moderated", true)
+	// Messages in moderated channels don't use up the lower limit
+	for range 20 {
+		limiter.take(now, newTestPrivateMessage("#moderated"))
+	}
+	if wait := limiter.wait(now, newTestPrivateMessage("#channel")); wait != 0 {
+		t.Errorf("waited %s after moderated messages, want 0", wait)
+	}
+	// But everything is still held to the higher limit
+	for range 80 {
+		limiter.take(now, newTestPrivateMessage("#moderated"))
+	}
+	if wait := limiter.wait(now, newTestPrivateMessage("#channel")); wait != time.Second*30 {
+		t.Errorf("waited %s after 100 messages, want %s", wait, time.Second*30)
+	}
+}
+
+func TestChatRateLimiterJoins(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newChatRateLimiter(ChatRateLimitsDefault)
+	batch := &IrcMessage{
+		Command: "JOIN",
+		Params:  []string{"#a,#b,#c,#d,#e,#f,#g,#h,#i,#j,#k,#l,#m,#n,#o"},
+	}
+	if tokens := limiter.joinTokens(batch); tokens != 15 {
+		t.Fatalf("batch of 15 channels used %d tokens", tokens)
+	}
+	limiter.take(now, batch)
+	// Five joins are left so a batch of six has to wait
+	small := &IrcMessage{
+		Command: "JOIN",
+		Params:  []string{"#p,#q,#r,#s,#t"},
+	}
+	if wait := limiter.wait(now, small); wait != 0 {
+		t.Errorf("waited %s for 5 joins, want 0", wait)
+	}
+	large := &IrcMessage{
+		Command: "JOIN",
+		Params:  []string{"#p,#q,#r,#s,#t,#u"},
+	}
+	if wait := limiter.wait(now, large); wait != time.Second*10 {
+		t.Errorf("waited %s for 6 joins, want %s", wait, time.Second*10)
+	}
+	// Joins and messages have their own limits
+	if wait := limiter.wait(now, newTestPrivateMessage("#a")); wait != 0 {
+		t.Errorf("message waited %s after joins, want 0", wait)
+	}
+}
+
+func TestChatRateLimiterUnlimited(t *testing.T) {
+	limiter := newChatRateLimiter(ChatRateLimitsDefault)
+	for _, command := range []string{"PING", "PONG", "PART", "CAP", "PASS", "NICK"} {
+		if limiter.isLimited(&IrcMessage{Command: command}) {
+			t.Errorf("%s is rate limited", command)
+		}
+	}
+}
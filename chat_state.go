@@ -0,0 +1,199 @@
+package twitch
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type chatStateStore struct {
+	channels map[string]*ChatChannelState
+	mutex    sync.RWMutex
+	self     ChatSelfState
+}
+
+func (s *chatStateStore) channel(channel string) (ChatChannelState, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	channelState, ok := s.channels[channel]
+	if !ok {
+		return ChatChannelState{}, false
+	}
+	return *channelState, true
+}
+
+func (s *chatStateStore) clear() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.channels = make(map[string]*ChatChannelState)
+}
+
+func (s *chatStateStore) getSelf() ChatSelfState {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.self
+}
+
+func (s *chatStateStore) removeChannel(channel string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.channels, channel)
+}
+
+func (s *chatStateStore) updateChannel(channel string, update func(channelState *ChatChannelState)) ChatChannelState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	channelState, ok := s.channels[channel]
+	if !ok {
+		channelState = &ChatChannelState{
+			Channel: channel,
+		}
+		s.channels[channel] = channelState
+	}
+	update(channelState)
+	return *channelState
+}
+
+func (s *chatStateStore) updateSelf(update func(selfState *ChatSelfState)) ChatSelfState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	update(&s.self)
+	return s.self
+}
+
+func (c *ChatClient) ChannelState(channel string) (ChatChannelState, bool) {
+	channel, err := normalizeChannel(channel)
+	if err != nil {
+		return ChatChannelState{}, false
+	}
+	return c.state.channel(channel)
+}
+
+func (c *ChatClient) handleGlobalUserState(parsedIrcMessage *IrcMessage) {
+	tags := parsedIrcMessage.Tags
+	selfState := c.state.updateSelf(func(selfState *ChatSelfState) {
+		selfState.Color = tags["color"]
+		selfState.DisplayName = tags["display-name"]
+		selfState.EmoteSets = nil
+		if tags["emote-sets"] != "" {
+			selfState.EmoteSets = strings.Split(tags["emote-sets"], ",")
+		}
+		selfState.UserId = tags["user-id"]
+	})
+	// Run handlers if loaded
+	if hasSubscribers[*ChatGlobalUserStateMessage](c) {
+		globalUserStateMessage := &ChatGlobalUserStateMessage{
+			State: selfState,
+			Tags:  tags,
+		}
+		c.emit(parsedIrcMessage, globalUserStateMessage)
+	}
+}
+
+func (c *ChatClient) handleRoomState(parsedIrcMessage *IrcMessage) {
+	tags := parsedIrcMessage.Tags
+	// Twitch only sends the settings that have changed after the first
+	// ROOMSTATE, so only update what is in the tags
+	channelState := c.state.updateChannel(parsedIrcMessage.Params[0], func(channelState *ChatChannelState) {
+		if value, ok := tags["emote-only"]; ok {
+			channelState.EmoteOnly = value == "1"
+		}
+		if value, ok := tags["followers-only"]; ok {
+			// -1 is off, otherwise it is the number of minutes someone must
+			// have been following for
+			minutes, err := strconv.Atoi(value)
+			channelState.FollowersOnly = err == nil && minutes >= 0
+			channelState.FollowersOnlyDuration = 0
+			if channelState.FollowersOnly {
+				channelState.FollowersOnlyDuration = time.Duration(minutes) * time.Minute
+			}
+		}
+		if value, ok := tags["r9k"]; ok {
+			channelState.R9k = value == "1"
+		}
+		if value, ok := tags["room-id"]; ok {
+			channelState.RoomId = value
+		}
+		if _, ok := tags["slow"]; ok {
+			channelState.SlowMode = time.Duration(parseIntTag(tags, "slow")) * time.Second
+		}
+		if value, ok := tags["subs-only"]; ok {
+			channelState.SubsOnly = value == "1"
+		}
+	})
+	// Run handlers if loaded
+	if hasSubscribers[*ChatRoomStateMessage](c) {
+		roomStateMessage := &ChatRoomStateMessage{
+			Channel: channelState.Channel,
+			State:   channelState,
+			Tags:    tags,
+		}
+		c.emit(parsedIrcMessage, roomStateMessage)
+	}
+}
+
+func (c *ChatClient) handleUserState(parsedIrcMessage *IrcMessage) {
+	tags := parsedIrcMessage.Tags
+	channelState := c.state.updateChannel(parsedIrcMessage.Params[0], func(channelState *ChatChannelState) {
+		channelState.IsBroadcaster = false
+		channelState.IsModerator = tags["mod"] == "1"
+		channelState.IsSubscriber = tags["subscriber"] == "1"
+		channelState.IsVip = false
+		for _, badge := range strings.Split(tags["badges"], ",") {
+			badgeName, _, _ := strings.Cut(badge, "/")
+			switch badgeName {
+			case "broadcaster":
+				channelState.IsBroadcaster = true
+			case "moderator":
+				channelState.IsModerator = true
+			case "subscriber", "founder":
+				channelState.IsSubscriber = true
+			case "vip":
+				channelState.IsVip = true
+			}
+		}
+	})
+	// Moderators, VIPs and the broadcaster get a higher message limit
+	c.rateLimiter.setModerator(channelState.Channel, channelState.IsBroadcaster || channelState.IsModerator || channelState.IsVip)
+	// Only the echo of a message we sent carries its id, the one sent after
+	// joining doesn't
+	if id, ok := tags["id"]; ok {
+		c.resolveDelivery(channelState.Channel, &ChatSendResult{
+			Channel: channelState.Channel,
+			Id:      id,
+			State:   channelState,
+		}, nil)
+	}
+	// Run handlers if loaded
+	if hasSubscribers[*ChatUserStateMessage](c) {
+		userStateMessage := &ChatUserStateMessage{
+			Channel: channelState.Channel,
+			State:   channelState,
+			Tags:    tags,
+		}
+		c.emit(parsedIrcMessage, userStateMessage)
+	}
+}
+
+func (c *ChatClient) OnGlobalUserState(handler func(message *ChatGlobalUserStateMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnRoomState(handler func(message *ChatRoomStateMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnUserState(handler func(message *ChatUserStateMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) Self() ChatSelfState {
+	return c.state.getSelf()
+}
+
+func newChatStateStore() *chatStateStore {
+	return &chatStateStore{
+		channels: make(map[string]*ChatChannelState),
+	}
+}
@@ -0,0 +1,418 @@
+package twitch_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ynotnauk/go-twitch"
+	"github.com/ynotnauk/go-twitch/twitchtest"
+)
+
+const (
+	testTimeout time.Duration = time.Second * 5
+)
+
+var (
+	testServers map[string]func() (*twitchtest.Server, error) = map[string]func() (*twitchtest.Server, error){
+		"tcp":       twitchtest.NewServer,
+		"websocket": twitchtest.NewWebSocketServer,
+	}
+)
+
+func runTestClient(t *testing.T, run func(ctx context.Context) error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan bool)
+	go func() {
+		defer close(stopped)
+		run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case <-stopped:
+		case <-time.After(testTimeout):
+			t.Error("client did not stop")
+		}
+	})
+}
+
+func sendEvent[T any](events chan<- T, event T) {
+	// Handlers must never block the client, a test that has stopped reading
+	// doesn't need the event anyway
+	select {
+	case events <- event:
+	default:
+	}
+}
+
+func startTestChatClient(t *testing.T, server *twitchtest.Server, setup func(chat *twitch.ChatClient)) *twitch.ChatClient {
+	t.Helper()
+	chat, err := twitch.NewChatClient(twitchtest.NewAuthProvider("testbot"), server.ClientOptions()...)
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	setup(chat)
+	// Wait for the welcome to be handled so the client is ready to use
+	connected := make(chan *twitch.ChatConnectMessage, 1)
+	chat.OnConnect(func(message *twitch.ChatConnectMessage) {
+		sendEvent(connected, message)
+	})
+	runTestClient(t, chat.Run)
+	waitForEvent(t, connected)
+	return chat
+}
+
+func startTestServer(t *testing.T, newServer func() (*twitchtest.Server, error)) *twitchtest.Server {
+	t.Helper()
+	server, err := newServer()
+	if err != nil {
+		t.Fatalf("unable to start server: %s", err)
+	}
+	t.Cleanup(func() {
+		server.Close()
+	})
+	return server
+}
+
+func waitForLine(t *testing.T, server *twitchtest.Server, prefix string) string {
+	t.Helper()
+	line, err := server.WaitForLine(prefix, testTimeout)
+	if err != nil {
+		t.Fatalf("waiting for %q: %s", prefix, err)
+	}
+	return line
+}
+
+func waitForEvent[T any](t *testing.T, events <-chan T) T {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(testTimeout):
+		t.Fatalf("timed out waiting for %s", reflect.TypeFor[T]())
+	}
+	return *new(T)
+}
+
+func TestChatClientHandshake(t *testing.T) {
+	for name, newServer := range testServers {
+		t.Run(name, func(t *testing.T) {
+			server := startTestServer(t, newServer)
+			chat, err := twitch.NewChatClient(twitchtest.NewAuthProvider("testbot"), server.ClientOptions()...)
+			if err != nil {
+				t.Fatalf("unable to create client: %s", err)
+			}
+			connected := make(chan *twitch.ChatConnectMessage, 1)
+			chat.OnConnect(func(message *twitch.ChatConnectMessage) {
+				sendEvent(connected, message)
+			})
+			runTestClient(t, chat.Run)
+			// The handshake is always sent in the same order
+			expected := []string{
+				"CAP REQ :twitch.tv/commands twitch.tv/membership twitch.tv/tags",
+				"PASS oauth:twitchtest",
+				"NICK testbot",
+			}
+			for _, line := range expected {
+				waitForLine(t, server, line)
+			}
+			err = server.WaitForHandshakes(1, testTimeout)
+			if err != nil {
+				t.Fatalf("waiting for handshake: %s", err)
+			}
+			message := waitForEvent(t, connected)
+			if message.Hostname != server.Address() {
+				t.Errorf("connected to %q, want %q", message.Hostname, server.Address())
+			}
+			received := server.Received()
+			for index, line := range expected {
+				if index >= len(received) || received[index] != line {
+					t.Fatalf("handshake was %q, want %q", received, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestChatClientJoinAndPart(t *testing.T) {
+	for name, newServer := range testServers {
+		t.Run(name, func(t *testing.T) {
+			server := startTestServer(t, newServer)
+			joins := make(chan *twitch.ChatJoinMessage, 1)
+			parts := make(chan *twitch.ChatPartMessage, 1)
+			chat := startTestChatClient(t, server, func(chat *twitch.ChatClient) {
+				chat.OnJoin(func(message *twitch.ChatJoinMessage) {
+					sendEvent(joins, message)
+				})
+				chat.OnPart(func(message *twitch.ChatPartMessage) {
+					sendEvent(parts, message)
+				})
+			})
+			err := chat.Join("Channel")
+			if err != nil {
+				t.Fatalf("unable to join: %s", err)
+			}
+			waitForLine(t, server, "JOIN #channel")
+			join := waitForEvent(t, joins)
+			if join.Channel != "#channel" || join.Username != "testbot" {
+				t.Errorf("joined %q as %q, want #channel as testbot", join.Channel, join.Username)
+			}
+			err = chat.Part("channel")
+			if err != nil {
+				t.Fatalf("unable to part: %s", err)
+			}
+			waitForLine(t, server, "PART #channel")
+			part := waitForEvent(t, parts)
+			if part.Channel != "#channel" || part.Username != "testbot" {
+				t.Errorf("parted %q as %q, want #channel as testbot", part.Channel, part.Username)
+			}
+		})
+	}
+}
+
+func TestChatClientPingPong(t *testing.T) {
+	for name, newServer := range testServers {
+		t.Run(name, func(t *testing.T) {
+			server := startTestServer(t, newServer)
+			pings := make(chan *twitch.ChatPingMessage, 1)
+			startTestChatClient(t, server, func(chat *twitch.ChatClient) {
+				chat.OnPing(func(message *twitch.ChatPingMessage) {
+					sendEvent(pings, message)
+				})
+			})
+			// The server pings and expects the same param back
+			server.Send("PING :tmi.twitch.tv")
+			waitForLine(t, server, "PONG tmi.twitch.tv")
+			waitForEvent(t, pings)
+		})
+	}
+}
+
+func TestChatClientShutdown(t *testing.T) {
+	server := startTestServer(t, twitchtest.NewServer)
+	chat, err := twitch.NewChatClient(twitchtest.NewAuthProvider("testbot"), server.ClientOptions()...)
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	connected := make(chan *twitch.ChatConnectMessage, 1)
+	chat.OnConnect(func(message *twitch.ChatConnectMessage) {
+		sendEvent(connected, message)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- chat.Run(ctx)
+	}()
+	waitForEvent(t, connected)
+	err = chat.Join("channel")
+	if err != nil {
+		t.Fatalf("unable to join: %s", err)
+	}
+	waitForLine(t, server, "JOIN #channel")
+	// Channels are left and the server told goodbye before closing
+	cancel()
+	waitForLine(t, server, "PART #channel")
+	waitForLine(t, server, "QUIT")
+	err = waitForEvent(t, stopped)
+	if err != context.Canceled {
+		t.Errorf("stopped with %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestChatClientMixedCaseLogin(t *testing.T) {
+	server := startTestServer(t, twitchtest.NewServer)
+	chat, err := twitch.NewChatClient(twitchtest.NewAuthProvider("TestBot"), server.ClientOptions()...)
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	connected := make(chan *twitch.ChatConnectMessage, 1)
+	chat.OnConnect(func(message *twitch.ChatConnectMessage) {
+		sendEvent(connected, message)
+	})
+	runTestClient(t, chat.Run)
+	waitForEvent(t, connected)
+	err = chat.Join("channel")
+	if err != nil {
+		t.Fatalf("unable to join: %s", err)
+	}
+	waitForLine(t, server, "JOIN #channel")
+	// Twitch echoes the join with the login in lowercase
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	for !slices.Contains(chat.Channels(), "#channel") {
+		if ctx.Err() != nil {
+			t.Fatalf("join was never confirmed, in %q", chat.Channels())
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	_, err = chat.SayWithResult(ctx, "channel", "hello")
+	if err != nil {
+		t.Errorf("unable to say: %s", err)
+	}
+}
+
+func TestChatClientAnonymous(t *testing.T) {
+	server := startTestServer(t, twitchtest.NewServer)
+	options := append(server.ClientOptions(), twitch.WithAnonymous())
+	chat, err := twitch.NewChatClient(nil, options...)
+	if err != nil {
+		t.Fatalf("unable to create client: %s", err)
+	}
+	connected := make(chan *twitch.ChatConnectMessage, 1)
+	chat.OnConnect(func(message *twitch.ChatConnectMessage) {
+		sendEvent(connected, message)
+	})
+	runTestClient(t, chat.Run)
+	waitForEvent(t, connected)
+	// Anonymous logins connect without a password
+	for _, line := range server.Received() {
+		if strings.HasPrefix(line, "PASS") {
+			t.Errorf("sent %q", line)
+		}
+	}
+	if !strings.HasPrefix(chat.Self().Login, "justinfan") {
+		t.Errorf("logged in as %q", chat.Self().Login)
+	}
+	err = chat.Say("channel", "hello")
+	if err != twitch.ErrReadOnly {
+		t.Errorf("got error %v, want %v", err, twitch.ErrReadOnly)
+	}
+}
+
+func TestChatClientSayWithResultRejected(t *testing.T) {
+	server := startTestServer(t, twitchtest.NewServer)
+	joined := make(chan *twitch.ChatJoinMessage, 1)
+	chat := startTestChatClient(t, server, func(chat *twitch.ChatClient) {
+		chat.OnJoin(func(message *twitch.ChatJoinMessage) {
+			sendEvent(joined, message)
+		})
+	})
+	err := chat.Join("channel")
+	if err != nil {
+		t.Fatalf("unable to join: %s", err)
+	}
+	waitForEvent(t, joined)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	server.RejectMessages("msg_duplicate")
+	_, err = chat.SayWithResult(ctx, "channel", "hello")
+	if !errors.Is(err, twitch.ErrMessageDuplicate) {
+		t.Errorf("got error %v, want %v", err, twitch.ErrMessageDuplicate)
+	}
+	// Going back to confirming messages lets them through again
+	server.RejectMessages("")
+	result, err := chat.SayWithResult(ctx, "channel", "hello again")
+	if err != nil {
+		t.Fatalf("unable to say: %s", err)
+	}
+	if result.Channel != "#channel" || result.Id == "" {
+		t.Errorf("got result %+v", result)
+	}
+}
+
+func TestChatClientSayWithResultAfterSay(t *testing.T) {
+	server := startTestServer(t, twitchtest.NewServer)
+	joined := make(chan *twitch.ChatJoinMessage, 1)
+	chat := startTestChatClient(t, server, func(chat *twitch.ChatClient) {
+		chat.OnJoin(func(message *twitch.ChatJoinMessage) {
+			sendEvent(joined, message)
+		})
+	})
+	err := chat.Join("channel")
+	if err != nil {
+		t.Fatalf("unable to join: %s", err)
+	}
+	waitForEvent(t, joined)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	// The answer to the plain message must not be taken as the answer to the
+	// one that is waiting
+	err = chat.Say("channel", "plain")
+	if err != nil {
+		t.Fatalf("unable to say: %s", err)
+	}
+	result, err := chat.SayWithResult(ctx, "channel", "waiting")
+	if err != nil {
+		t.Fatalf("unable to say: %s", err)
+	}
+	if result.Id != "message-2" {
+		t.Errorf("got id %q, want %q", result.Id, "message-2")
+	}
+}
+
+func TestChatClientReconnect(t *testing.T) {
+	drops := map[string]func(server *twitchtest.Server){
+		"disconnect": func(server *twitchtest.Server) {
+			server.Disconnect()
+		},
+		"reconnect command": func(server *twitchtest.Server) {
+			server.Send(":tmi.twitch.tv RECONNECT")
+		},
+	}
+	for name, drop := range drops {
+		t.Run(name, func(t *testing.T) {
+			server := startTestServer(t, twitchtest.NewServer)
+			reconnects := make(chan *twitch.ChatReconnectMessage, 1)
+			chat := startTestChatClient(t, server, func(chat *twitch.ChatClient) {
+				chat.OnReconnect(func(message *twitch.ChatReconnectMessage) {
+					sendEvent(reconnects, message)
+				})
+			})
+			err := chat.Join("channel")
+			if err != nil {
+				t.Fatalf("unable to join: %s", err)
+			}
+			waitForLine(t, server, "JOIN #channel")
+			drop(server)
+			// The client logs in again and goes back to the channels it was in
+			err = server.WaitForHandshakes(2, testTimeout)
+			if err != nil {
+				t.Fatalf("waiting for second handshake: %s", err)
+			}
+			waitForLine(t, server, "JOIN #channel")
+			received := server.Received()
+			lastNick := 0
+			for index, line := range received {
+				if line == "NICK testbot" {
+					lastNick = index
+				}
+			}
+			if !slices.Contains(received[lastNick:], "JOIN #channel") {
+				t.Errorf("channel was not joined after logging in again, got %q", received)
+			}
+			reconnect := waitForEvent(t, reconnects)
+			if reconnect.Attempts > 1 {
+				t.Errorf("took %d attempts to reconnect", reconnect.Attempts)
+			}
+		})
+	}
+}
+
+func TestChatClientReply(t *testing.T) {
+	server := startTestServer(t, twitchtest.NewServer)
+	chat := startTestChatClient(t, server, func(chat *twitch.ChatClient) {})
+	err := chat.Reply(&twitch.ChatPrivateMessage{Channel: "#channel", Id: "message-1"}, "threaded")
+	if err != nil {
+		t.Fatalf("unable to reply: %s", err)
+	}
+	// A message without an id can't be replied to so it is sent on its own
+	err = chat.Reply(&twitch.ChatPrivateMessage{Channel: "#channel"}, "unthreaded")
+	if err != nil {
+		t.Fatalf("unable to reply: %s", err)
+	}
+	for _, expected := range []string{
+		"@reply-parent-msg-id=message-1 PRIVMSG #channel threaded",
+		"PRIVMSG #channel unthreaded",
+	} {
+		line := waitForLine(t, server, expected)
+		if line != expected {
+			t.Errorf("sent %q, want %q", line, expected)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package twitch
+
+import (
+	"strconv"
+)
+
+const (
+	anonymousGifterLogin string = "ananonymousgifter"
+)
+
+func (c *ChatClient) handleUserNotice(parsedIrcMessage *IrcMessage) {
+	tags := parsedIrcMessage.Tags
+	userNoticeMessage := ChatUserNoticeMessage{
+		Channel:       parsedIrcMessage.Params[0],
+		DisplayName:   tags["display-name"],
+		Id:            tags["id"],
+		Login:         tags["login"],
+		SystemMessage: tags["system-msg"],
+		Tags:          tags,
+		Type:          tags["msg-id"],
+		UserId:        tags["user-id"],
+	}
+	// The message the user attached is optional
+	if len(parsedIrcMessage.Params) > 1 {
+		userNoticeMessage.Message = parsedIrcMessage.Params[1]
+	}
+	// Run handlers if loaded
+	c.emit(parsedIrcMessage, &userNoticeMessage)
+	// Run the handlers for the specific type of notice
+	switch userNoticeMessage.Type {
+	case "announcement":
+		if hasSubscribers[*ChatAnnouncementMessage](c) {
+			announcementMessage := &ChatAnnouncementMessage{
+				ChatUserNoticeMessage: userNoticeMessage,
+				Color:                 tags["msg-param-color"],
+			}
+			c.emit(parsedIrcMessage, announcementMessage)
+		}
+	case "bitsbadgetier":
+		if hasSubscribers[*ChatBitsBadgeTierMessage](c) {
+			bitsBadgeTierMessage := &ChatBitsBadgeTierMessage{
+				ChatUserNoticeMessage: userNoticeMessage,
+				Threshold:             parseIntTag(tags, "msg-param-threshold"),
+			}
+			c.emit(parsedIrcMessage, bitsBadgeTierMessage)
+		}
+	case "raid":
+		if hasSubscribers[*ChatRaidMessage](c) {
+			raidMessage := &ChatRaidMessage{
+				ChatUserNoticeMessage: userNoticeMessage,
+				ViewerCount:           parseIntTag(tags, "msg-param-viewerCount"),
+			}
+			c.emit(parsedIrcMessage, raidMessage)
+		}
+	case "ritual":
+		if hasSubscribers[*ChatRitualMessage](c) {
+			ritualMessage := &ChatRitualMessage{
+				ChatUserNoticeMessage: userNoticeMessage,
+				RitualName:            tags["msg-param-ritual-name"],
+			}
+			c.emit(parsedIrcMessage, ritualMessage)
+		}
+	case "sub", "resub":
+		if hasSubscribers[*ChatSubMessage](c) {
+			subMessage := &ChatSubMessage{
+				ChatUserNoticeMessage: userNoticeMessage,
+				CumulativeMonths:      parseIntTag(tags, "msg-param-cumulative-months"),
+				IsResub:               userNoticeMessage.Type == "resub",
+				ShouldShareStreak:     tags["msg-param-should-share-streak"] == "1",
+				StreakMonths:          parseIntTag(tags, "msg-param-streak-months"),
+				SubPlan:               tags["msg-param-sub-plan"],
+				SubPlanName:           tags["msg-param-sub-plan-name"],
+			}
+			c.emit(parsedIrcMessage, subMessage)
+		}
+	case "subgift", "anonsubgift":
+		if hasSubscribers[*ChatSubGiftMessage](c) {
+			subGiftMessage := &ChatSubGiftMessage{
+				ChatUserNoticeMessage: userNoticeMessage,
+				GiftMonths:            parseIntTag(tags, "msg-param-gift-months"),
+				IsAnonymous:           userNoticeMessage.Type == "anonsubgift" || userNoticeMessage.Login == anonymousGifterLogin,
+				Months:                parseIntTag(tags, "msg-param-months"),
+				RecipientDisplayName:  tags["msg-param-recipient-display-name"],
+				RecipientLogin:        tags["msg-param-recipient-user-name"],
+				RecipientUserId:       tags["msg-param-recipient-id"],
+				SubPlan:               tags["msg-param-sub-plan"],
+				SubPlanName:           tags["msg-param-sub-plan-name"],
+			}
+			c.emit(parsedIrcMessage, subGiftMessage)
+		}
+	case "submysterygift", "anonsubmysterygift":
+		if hasSubscribers[*ChatSubMysteryGiftMessage](c) {
+			subMysteryGiftMessage := &ChatSubMysteryGiftMessage{
+				ChatUserNoticeMessage: userNoticeMessage,
+				GiftCount:             parseIntTag(tags, "msg-param-mass-gift-count"),
+				IsAnonymous:           userNoticeMessage.Type == "anonsubmysterygift" || userNoticeMessage.Login == anonymousGifterLogin,
+				SenderCount:           parseIntTag(tags, "msg-param-sender-count"),
+				SubPlan:               tags["msg-param-sub-plan"],
+			}
+			c.emit(parsedIrcMessage, subMysteryGiftMessage)
+		}
+	}
+}
+
+func (c *ChatClient) OnAnnouncement(handler func(message *ChatAnnouncementMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnBitsBadgeTier(handler func(message *ChatBitsBadgeTierMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnRaid(handler func(message *ChatRaidMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnRitual(handler func(message *ChatRitualMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnSub(handler func(message *ChatSubMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnSubGift(handler func(message *ChatSubGiftMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnSubMysteryGift(handler func(message *ChatSubMysteryGiftMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnUserNotice(handler func(message *ChatUserNoticeMessage)) {
+	On(c, handler)
+}
+
+func parseIntTag(tags map[string]string, key string) int {
+	// Missing or malformed numbers are treated as zero
+	value, err := strconv.Atoi(tags[key])
+	if err != nil {
+		return 0
+	}
+	return value
+}
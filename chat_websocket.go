@@ -0,0 +1,260 @@
+package twitch
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	websocketServerAddress string        = "irc-ws.chat.twitch.tv:443"
+	websocketAcceptGuid    string        = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	websocketCloseTimeout  time.Duration = time.Second * 1
+	websocketMaxFrameSize  uint64        = 1 << 20
+)
+
+const (
+	websocketOpcodeContinuation byte = 0x0
+	websocketOpcodeText         byte = 0x1
+	websocketOpcodeBinary       byte = 0x2
+	websocketOpcodeClose        byte = 0x8
+	websocketOpcodePing         byte = 0x9
+	websocketOpcodePong         byte = 0xA
+)
+
+var (
+	ErrInvalidWebSocketFrame    error = errors.New("invalid websocket frame")
+	ErrWebSocketFrameTooLarge   error = errors.New("websocket frame is too large")
+	ErrWebSocketHandshakeFailed error = errors.New("websocket handshake failed")
+)
+
+type websocketConnection struct {
+	closeOnce      sync.Once
+	connection     net.Conn
+	frameRemaining uint64
+	frameFinal     bool
+	frameHasData   bool
+	lastByte       byte
+	lineEnd        []byte
+	reader         *bufio.Reader
+	writeMutex     sync.Mutex
+}
+
+func (w *websocketConnection) Close() error {
+	// Let the server know we are going away, this is best effort as the
+	// connection may already be dead
+	w.closeOnce.Do(func() {
+		w.connection.SetWriteDeadline(time.Now().Add(websocketCloseTimeout))
+		w.writeFrame(websocketOpcodeClose, nil)
+	})
+	return w.connection.Close()
+}
+
+func (w *websocketConnection) Read(p []byte) (int, error) {
+	for {
+		// Finish off a line the server didn't terminate before starting the
+		// next frame so lines from separate frames never run together
+		if len(w.lineEnd) > 0 {
+			n := copy(p, w.lineEnd)
+			w.lineEnd = w.lineEnd[n:]
+			return n, nil
+		}
+		if w.frameRemaining > 0 {
+			size := min(uint64(len(p)), w.frameRemaining)
+			n, err := w.reader.Read(p[:size])
+			if n > 0 {
+				w.frameRemaining -= uint64(n)
+				w.frameHasData = true
+				w.lastByte = p[n-1]
+				w.endMessage()
+			}
+			return n, err
+		}
+		err := w.readFrameHeader()
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (w *websocketConnection) endMessage() {
+	if w.frameRemaining > 0 || !w.frameFinal {
+		return
+	}
+	if w.frameHasData && w.lastByte != '\n' {
+		w.lineEnd = []byte("\r\n")
+	}
+	w.frameHasData = false
+}
+
+func (w *websocketConnection) readFrameHeader() error {
+	header := make([]byte, 2)
+	_, err := io.ReadFull(w.reader, header)
+	if err != nil {
+		return err
+	}
+	final := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+	// Work out the real length of the payload
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		_, err = io.ReadFull(w.reader, extended)
+		if err != nil {
+			return err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		_, err = io.ReadFull(w.reader, extended)
+		if err != nil {
+			return err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+	// Servers must never mask frames sent to a client
+	if masked {
+		return ErrInvalidWebSocketFrame
+	}
+	switch opcode {
+	case websocketOpcodeText, websocketOpcodeBinary, websocketOpcodeContinuation:
+		if length > websocketMaxFrameSize {
+			return ErrWebSocketFrameTooLarge
+		}
+		w.frameFinal = final
+		w.frameRemaining = length
+		w.endMessage()
+		return nil
+	case websocketOpcodeClose, websocketOpcodePing, websocketOpcodePong:
+		// Control frames are small and can't be fragmented
+		if length > 125 || !final {
+			return ErrInvalidWebSocketFrame
+		}
+		payload := make([]byte, length)
+		_, err = io.ReadFull(w.reader, payload)
+		if err != nil {
+			return err
+		}
+		switch opcode {
+		case websocketOpcodeClose:
+			w.closeOnce.Do(func() {
+				w.writeFrame(websocketOpcodeClose, payload)
+			})
+			return io.EOF
+		case websocketOpcodePing:
+			return w.writeFrame(websocketOpcodePong, payload)
+		}
+		return nil
+	default:
+		return ErrInvalidWebSocketFrame
+	}
+}
+
+func (w *websocketConnection) SetWriteDeadline(t time.Time) error {
+	return w.connection.SetWriteDeadline(t)
+}
+
+func (w *websocketConnection) Write(p []byte) (int, error) {
+	// Every write is sent as a single text frame
+	err := w.writeFrame(websocketOpcodeText, p)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *websocketConnection) writeFrame(opcode byte, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|opcode)
+	// Clients must always mask the payload
+	length := len(payload)
+	switch {
+	case length <= 125:
+		frame = append(frame, 0x80|byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+	mask := make([]byte, 4)
+	_, err := rand.Read(mask)
+	if err != nil {
+		return err
+	}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	_, err = w.connection.Write(frame)
+	return err
+}
+
+func newWebSocketConnection(ctx context.Context, connection net.Conn, host string) (*websocketConnection, error) {
+	// Give up on the handshake if the context ends before it completes
+	stop := context.AfterFunc(ctx, func() {
+		connection.SetDeadline(time.Now())
+	})
+	defer stop()
+	// Create a random key for the server to prove it understood the upgrade
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	request, err := http.NewRequest(http.MethodGet, "http://"+host+"/", nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Sec-WebSocket-Key", key)
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	request.Header.Set("Upgrade", "websocket")
+	err = request.Write(connection)
+	if err != nil {
+		return nil, err
+	}
+	// The reader is kept as it may already hold the first frames
+	reader := bufio.NewReader(connection)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusSwitchingProtocols ||
+		!strings.EqualFold(response.Header.Get("Upgrade"), "websocket") ||
+		response.Header.Get("Sec-WebSocket-Accept") != websocketAcceptKey(key) {
+		return nil, fmt.Errorf("%w: %s", ErrWebSocketHandshakeFailed, response.Status)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	connection.SetDeadline(time.Time{})
+	transport := &websocketConnection{
+		connection: connection,
+		reader:     reader,
+	}
+	return transport, nil
+}
+
+func websocketAcceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + websocketAcceptGuid))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
@@ -0,0 +1,50 @@
+package twitch
+
+import (
+	"context"
+	"strings"
+)
+
+func (c *ChatClient) handleWhisper(parsedIrcMessage *IrcMessage) {
+	tags := parsedIrcMessage.Tags
+	whisperMessage := &ChatWhisperMessage{
+		Badges:      parseBadgesTag(tags["badges"]),
+		DisplayName: tags["display-name"],
+		Login:       parsedIrcMessage.Source.Nickname,
+		MessageId:   tags["message-id"],
+		Tags:        tags,
+		ThreadId:    tags["thread-id"],
+		UserId:      tags["user-id"],
+	}
+	if len(parsedIrcMessage.Params) > 1 {
+		whisperMessage.Message = parsedIrcMessage.Params[1]
+	}
+	// Run handlers if loaded
+	c.emit(parsedIrcMessage, whisperMessage)
+}
+
+func (c *ChatClient) OnWhisper(handler func(message *ChatWhisperMessage)) {
+	On(c, handler)
+}
+
+func (c *ChatClient) OnWhisperContext(handler func(ctx context.Context, message *ChatWhisperMessage)) {
+	OnContext(c, handler)
+}
+
+func parseBadgesTag(rawBadges string) []Badge {
+	if rawBadges == "" {
+		return nil
+	}
+	var badges []Badge
+	for _, rawBadge := range strings.Split(rawBadges, ",") {
+		badgeName, badgeVersion, _ := strings.Cut(rawBadge, "/")
+		if badgeName == "" {
+			continue
+		}
+		badges = append(badges, Badge{
+			Name:    badgeName,
+			Version: badgeVersion,
+		})
+	}
+	return badges
+}
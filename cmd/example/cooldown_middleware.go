@@ -0,0 +1,30 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ynotnauk/go-twitch"
+)
+
+func CooldownMiddleware(cooldown time.Duration) twitch.CommandMiddleware {
+	lastUsed := make(map[string]time.Time)
+	mutex := sync.Mutex{}
+	return func(next twitch.CommandHandler) twitch.CommandHandler {
+		return func(context *twitch.ChatCommandContext) {
+			key := context.Message.Channel + " " + context.CommandName
+			mutex.Lock()
+			onCooldown := time.Since(lastUsed[key]) < cooldown
+			if !onCooldown {
+				lastUsed[key] = time.Now()
+			}
+			mutex.Unlock()
+			// Answer straight away without running the command
+			if onCooldown {
+				context.Reply(context.Message, "That command is on cooldown")
+				return
+			}
+			next(context)
+		}
+	}
+}
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/ynotnauk/go-twitch"
@@ -26,10 +31,28 @@ func main() {
 	}
 	// Add Chat Commands
 	bot.OnChatCommand("test", &HelloChatCommand{})
+	bot.UseCommandMiddleware(
+		func(next twitch.CommandHandler) twitch.CommandHandler {
+			return func(context *twitch.ChatCommandContext) {
+				log.Printf("[%s] %s used !%s", context.Message.Channel, context.Message.DisplayName, context.CommandName)
+				next(context)
+			}
+		},
+		CooldownMiddleware(time.Second*10),
+	)
 	// Handlers
 	bot.OnChatConnect(func(message *twitch.ChatConnectMessage) {
 		bot.ChatJoin("ynotnauk")
 	})
+	bot.OnChatDisconnect(func(message *twitch.ChatDisconnectMessage) {
+		log.Printf("Disconnected from chat: %v", message.Error)
+	})
+	bot.OnChatReconnect(func(message *twitch.ChatReconnectMessage) {
+		log.Printf("Reconnected to chat after %s (%d attempts)",
+			message.Downtime,
+			message.Attempts,
+		)
+	})
 	bot.OnChatJoin(func(message *twitch.ChatJoinMessage) {
 		log.Printf("[%s] %s has joined the channel",
 			message.Channel,
@@ -47,15 +70,24 @@ func main() {
 		latency := now - message.Timestamp
 		log.Printf("Current Latency: %v ms", latency)
 	})
+	bot.OnChatRaid(func(message *twitch.ChatRaidMessage) {
+		bot.ChatSay(message.Channel, fmt.Sprintf("Thanks for the raid %s!", message.DisplayName))
+	})
+	bot.OnChatSub(func(message *twitch.ChatSubMessage) {
+		bot.ChatSay(message.Channel, fmt.Sprintf("Thanks for the sub %s!", message.DisplayName))
+	})
 	bot.OnChatPrivateMessage(func(message *twitch.ChatPrivateMessage) {
 		log.Printf(
 			"[%s] <%s:%s> %s",
 			message.Channel,
-			message.Tags["user-id"],
-			message.Tags["display-name"],
+			message.UserId,
+			message.DisplayName,
 			message.Message,
 		)
 	})
+	// Stop the bot cleanly when asked to shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	// Start bot
-	bot.Start()
+	bot.Run(ctx)
 }
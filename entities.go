@@ -1,5 +1,10 @@
 package twitch
 
+import (
+	"context"
+	"time"
+)
+
 type AuthRecord struct {
 	AccessToken  string   `json:"accessToken"`
 	ClientId     string   `json:"clientId"`
@@ -12,28 +17,117 @@ type AuthRecord struct {
 	UserId       string   `json:"userId"`
 }
 
+type Badge struct {
+	Name    string
+	Version string
+}
+
+type ChatAnnouncementMessage struct {
+	ChatUserNoticeMessage
+	Color string
+}
+
+type ChatBitsBadgeTierMessage struct {
+	ChatUserNoticeMessage
+	Threshold int
+}
+
+type ChatChannelState struct {
+	Channel               string
+	EmoteOnly             bool
+	FollowersOnly         bool
+	FollowersOnlyDuration time.Duration
+	IsBroadcaster         bool
+	IsModerator           bool
+	IsSubscriber          bool
+	IsVip                 bool
+	R9k                   bool
+	RoomId                string
+	SlowMode              time.Duration
+	SubsOnly              bool
+}
+
+type ChatClearChatMessage struct {
+	Channel      string
+	Duration     time.Duration
+	IsBan        bool
+	IsTimeout    bool
+	RoomId       string
+	Tags         map[string]string
+	TargetLogin  string
+	TargetUserId string
+}
+
+type ChatClearMessageMessage struct {
+	Channel         string
+	Login           string
+	Message         string
+	Tags            map[string]string
+	TargetMessageId string
+}
+
 type ChatCommandContext struct {
 	CommandName   string
 	CommandParams []string
+	Context       context.Context
 	Message       *ChatPrivateMessage
-	Reply         func(message *ChatPrivateMessage, response string)
-	Say           func(channel string, message string)
+	Permission    ChatPermission
+	Reply         func(message *ChatPrivateMessage, response string) error
+	Say           func(channel string, message string) error
+	Whisper       *ChatWhisperMessage
 }
 
 type ChatConnectMessage struct {
 	Hostname string
 }
 
+type ChatDisconnectMessage struct {
+	Error error
+}
+
+type ChatFragment struct {
+	Bits        int
+	EmoteId     string
+	Login       string
+	Prefix      string
+	Text        string
+	Type        ChatFragmentType
+	UrlTemplate string
+}
+
+type ChatFragmentType string
+
+type ChatGlobalUserStateMessage struct {
+	State ChatSelfState
+	Tags  map[string]string
+}
+
+type ChatJoinFailedMessage struct {
+	Channel string
+	Error   error
+}
+
 type ChatJoinMessage struct {
 	Channel  string
 	Username string
 }
 
+type ChatNoticeMessage struct {
+	Channel string
+	Message string
+	Tags    map[string]string
+	Type    ChatNoticeType
+}
+
+type ChatNoticeType string
+
 type ChatPartMessage struct {
 	Channel  string
 	Username string
 }
 
+type ChatPermission int
+
 type ChatPingMessage struct{}
 
 type ChatPongMessage struct {
@@ -42,10 +136,146 @@ type ChatPongMessage struct {
 }
 
 type ChatPrivateMessage struct {
-	Channel  string
-	Message  string
-	Tags     map[string]string
-	Username string
+	Badges             []Badge
+	Bits               int
+	Channel            string
+	Color              string
+	DisplayName        string
+	Emotes             []EmoteRange
+	Id                 string
+	IsFirstMessage     bool
+	IsReturningChatter bool
+	Message            string
+	ReplyParent        *ReplyParent
+	RoomId             string
+	SentAt             time.Time
+	SubscriberMonths   int
+	Tags               map[string]string
+	UserId             string
+	Username           string
+}
+
+type ChatQueueOverflowMessage struct {
+	Message *IrcMessage
+}
+
+type ChatRaidMessage struct {
+	ChatUserNoticeMessage
+	ViewerCount int
+}
+
+type ChatRateLimits struct {
+	JoinLimit             int
+	JoinPeriod            time.Duration
+	MessageLimit          int
+	MessagePeriod         time.Duration
+	ModeratorMessageLimit int
+}
+
+type ChatReconnectMessage struct {
+	Attempts int
+	Downtime time.Duration
+}
+
+type ChatRitualMessage struct {
+	ChatUserNoticeMessage
+	RitualName string
+}
+
+type ChatRoomStateMessage struct {
+	Channel string
+	State   ChatChannelState
+	Tags    map[string]string
+}
+
+type ChatSelfState struct {
+	Color       string
+	DisplayName string
+	EmoteSets   []string
+	Login       string
+	UserId      string
+}
+
+type ChatSendResult struct {
+	Channel string
+	Id      string
+	State   ChatChannelState
+}
+
+type ChatSubGiftMessage struct {
+	ChatUserNoticeMessage
+	GiftMonths           int
+	IsAnonymous          bool
+	Months               int
+	RecipientDisplayName string
+	RecipientLogin       string
+	RecipientUserId      string
+	SubPlan              string
+	SubPlanName          string
+}
+
+type ChatSubMessage struct {
+	ChatUserNoticeMessage
+	CumulativeMonths  int
+	IsResub           bool
+	ShouldShareStreak bool
+	StreakMonths      int
+	SubPlan           string
+	SubPlanName       string
+}
+
+type ChatSubMysteryGiftMessage struct {
+	ChatUserNoticeMessage
+	GiftCount   int
+	IsAnonymous bool
+	SenderCount int
+	SubPlan     string
+}
+
+type ChatUserNoticeMessage struct {
+	Channel       string
+	DisplayName   string
+	Id            string
+	Login         string
+	Message       string
+	SystemMessage string
+	Tags          map[string]string
+	Type          string
+	UserId        string
+}
+
+type ChatUserStateMessage struct {
+	Channel string
+	State   ChatChannelState
+	Tags    map[string]string
+}
+
+type ChatWhisperMessage struct {
+	Badges      []Badge
+	DisplayName string
+	Login       string
+	Message     string
+	MessageId   string
+	Tags        map[string]string
+	ThreadId    string
+	UserId      string
+}
+
+type EmoteRange struct {
+	End   int
+	Id    string
+	Name  string
+	Start int
+}
+
+type HelixErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+	Status  int    `json:"status"`
+}
+
+type HelixWhisperRequest struct {
+	Message string `json:"message"`
 }
 
 type IrcMessage struct {
@@ -76,6 +306,16 @@ type RefreshTokenSuccess struct {
 	TokenType    string   `json:"token_type"`
 }
 
+type ReplyParent struct {
+	DisplayName     string
+	Login           string
+	Message         string
+	MessageId       string
+	ThreadLogin     string
+	ThreadMessageId string
+	UserId          string
+}
+
 type ValidateTokenFailed struct {
 	Message string `json:"message"`
 	Status  string `json:"status"`
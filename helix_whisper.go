@@ -0,0 +1,140 @@
+package twitch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	helixWhispersEndpoint string = "https://api.twitch.tv/helix/whispers"
+)
+
+var (
+	ErrBlankWhisperMessage error = errors.New("whisper message cannot be blank")
+	ErrBlankWhisperUserId  error = errors.New("toUserId cannot be blank")
+	ErrNilAuthProvider     error = errors.New("authProvider cannot be nil")
+)
+
+type HelixWhisperSender struct {
+	authProvider       AuthProvider
+	httpClient         *http.Client
+	validatedToken     string
+	validatedTokenInfo *ValidateTokenSuccess
+	validateMutex      sync.Mutex
+}
+
+func (s *HelixWhisperSender) SendWhisper(toUserId string, message string) error {
+	if toUserId == "" {
+		return ErrBlankWhisperUserId
+	}
+	if message == "" {
+		return ErrBlankWhisperMessage
+	}
+	// Get the token to send the whisper with
+	accessToken, err := s.authProvider.GetAccessToken()
+	if err != nil {
+		return err
+	}
+	// Helix needs the client and user id that the token belongs to
+	validateTokenSuccess, err := s.validateAccessToken(accessToken)
+	if err != nil {
+		return err
+	}
+	// Create body to send to the server
+	body, err := json.Marshal(&HelixWhisperRequest{
+		Message: message,
+	})
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	query.Set("from_user_id", validateTokenSuccess.UserId)
+	query.Set("to_user_id", toUserId)
+	// Create request
+	request, err := http.NewRequest(http.MethodPost, helixWhispersEndpoint+"?"+query.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	// Set request headers
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	request.Header.Set("Client-Id", validateTokenSuccess.ClientId)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", userAgent)
+	// Send request
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	// Twitch responds with no content when the whisper was sent
+	if response.StatusCode != http.StatusNoContent {
+		helixError := &HelixErrorResponse{}
+		err := json.NewDecoder(response.Body).Decode(helixError)
+		if err != nil {
+			return err
+		}
+		return errors.New(helixError.Message)
+	}
+	return nil
+}
+
+func (s *HelixWhisperSender) validateAccessToken(accessToken string) (*ValidateTokenSuccess, error) {
+	s.validateMutex.Lock()
+	defer s.validateMutex.Unlock()
+	// Only validate the token again if it has changed
+	if s.validatedTokenInfo != nil && s.validatedToken == accessToken {
+		return s.validatedTokenInfo, nil
+	}
+	// Create request
+	request, err := http.NewRequest(http.MethodGet, tokenValidationEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Set request headers
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	request.Header.Set("User-Agent", userAgent)
+	// Send request
+	response, err := s.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	// If the response is not 200 then the token is invalid
+	if response.StatusCode != 200 {
+		validateTokenFailed := &ValidateTokenFailed{}
+		err := json.NewDecoder(response.Body).Decode(validateTokenFailed)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New(validateTokenFailed.Message)
+	}
+	// Decode response
+	validateTokenSuccess := &ValidateTokenSuccess{}
+	err = json.NewDecoder(response.Body).Decode(validateTokenSuccess)
+	if err != nil {
+		return nil, err
+	}
+	s.validatedToken = accessToken
+	s.validatedTokenInfo = validateTokenSuccess
+	return validateTokenSuccess, nil
+}
+
+func NewHelixWhisperSender(authProvider AuthProvider) (*HelixWhisperSender, error) {
+	if authProvider == nil {
+		return nil, ErrNilAuthProvider
+	}
+	httpClient := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	sender := &HelixWhisperSender{
+		authProvider: authProvider,
+		httpClient:   httpClient,
+	}
+	return sender, nil
+}
@@ -1,5 +1,13 @@
 package twitch
 
+import (
+	"context"
+	"io"
+	"net"
+	"reflect"
+	"time"
+)
+
 type AuthProvider interface {
 	GetAccessToken() (string, error)
 	GetLoginAndAccessToken() (string, string, error)
@@ -13,3 +21,29 @@ type AuthStorer interface {
 type ChatCommander interface {
 	Execute(message *ChatCommandContext)
 }
+
+type ChatTransport interface {
+	io.ReadWriteCloser
+	SetWriteDeadline(t time.Time) error
+}
+
+type ChatPermissionedCommander interface {
+	ChatCommander
+	Permission() ChatPermission
+}
+
+type ContextDialer interface {
+	DialContext(ctx context.Context, network string, address string) (net.Conn, error)
+}
+
+type Event interface {
+	isChatEvent()
+}
+
+type EventSource interface {
+	subscribe(eventType reflect.Type, handler func(ctx context.Context, event Event)) func()
+}
+
+type WhisperSender interface {
+	SendWhisper(toUserId string, message string) error
+}
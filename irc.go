@@ -0,0 +1,252 @@
+package twitch
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ircTagValueEscaper *strings.Replacer = strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\:",
+		" ", "\\s",
+		"\r", "\\r",
+		"\n", "\\n",
+	)
+)
+
+var (
+	ErrBlankIrcCommand         error = errors.New("irc message is missing a command")
+	ErrBlankIrcMessageSource   error = errors.New("irc message source cannot be blank")
+	ErrBlankIrcMessageTags     error = errors.New("irc message tags cannot be blank")
+	ErrBlankRawIrcMessage      error = errors.New("rawIrcMessage cannot be blank")
+	ErrInvalidIrcCommand       error = errors.New("irc command contains invalid characters")
+	ErrInvalidIrcMessageSource error = errors.New("irc message source contains invalid characters")
+	ErrInvalidIrcParam         error = errors.New("irc param contains invalid characters")
+	ErrInvalidIrcTagKey        error = errors.New("irc tag key contains invalid characters")
+)
+
+func (m *IrcMessage) Marshal() (string, error) {
+	// Ensure there is a valid command
+	if m.Command == "" {
+		return "", ErrBlankIrcCommand
+	}
+	// Commands are either words or numeric replies
+	isInvalidCommandCharacter := func(character rune) bool {
+		return (character < 'A' || character > 'Z') && (character < 'a' || character > 'z') && (character < '0' || character > '9')
+	}
+	if strings.ContainsFunc(m.Command, isInvalidCommandCharacter) {
+		return "", ErrInvalidIrcCommand
+	}
+	line := &strings.Builder{}
+	// Tags are sorted so the output is always the same
+	if len(m.Tags) > 0 {
+		tagKeys := make([]string, 0, len(m.Tags))
+		for tagKey := range m.Tags {
+			if tagKey == "" || strings.ContainsAny(tagKey, " ;=\r\n\x00") {
+				return "", ErrInvalidIrcTagKey
+			}
+			tagKeys = append(tagKeys, tagKey)
+		}
+		slices.Sort(tagKeys)
+		line.WriteString("@")
+		for index, tagKey := range tagKeys {
+			if index > 0 {
+				line.WriteString(";")
+			}
+			line.WriteString(tagKey)
+			if m.Tags[tagKey] != "" {
+				line.WriteString("=")
+				line.WriteString(escapeIrcTagValue(m.Tags[tagKey]))
+			}
+		}
+		line.WriteString(" ")
+	}
+	// Message source
+	if m.Source != nil {
+		// The separators can only appear where they separate, otherwise the
+		// source would be read back as something else
+		if strings.ContainsAny(m.Source.Nickname, "!@") || strings.Contains(m.Source.Username, "@") {
+			return "", ErrInvalidIrcMessageSource
+		}
+		if m.Source.Nickname == "" && (m.Source.Username != "" || strings.ContainsAny(m.Source.Host, "!@")) {
+			return "", ErrInvalidIrcMessageSource
+		}
+		source := m.Source.Host
+		if m.Source.Nickname != "" {
+			source = m.Source.Nickname
+			if m.Source.Username != "" {
+				source = fmt.Sprintf("%s!%s", source, m.Source.Username)
+			}
+			if m.Source.Host != "" {
+				source = fmt.Sprintf("%s@%s", source, m.Source.Host)
+			}
+		}
+		if source == "" || strings.ContainsAny(source, " \r\n\x00") {
+			return "", ErrInvalidIrcMessageSource
+		}
+		line.WriteString(":")
+		line.WriteString(source)
+		line.WriteString(" ")
+	}
+	line.WriteString(m.Command)
+	// Params, line breaks are never allowed as they would start a new message
+	for index, param := range m.Params {
+		if strings.ContainsAny(param, "\r\n\x00") {
+			return "", ErrInvalidIrcParam
+		}
+		line.WriteString(" ")
+		// Only the last param can be empty, contain spaces or start with :
+		if param == "" || strings.Contains(param, " ") || strings.HasPrefix(param, ":") {
+			if index != len(m.Params)-1 {
+				return "", ErrInvalidIrcParam
+			}
+			line.WriteString(":")
+		}
+		line.WriteString(param)
+	}
+	return line.String(), nil
+}
+
+func (m *IrcMessage) String() string {
+	line, err := m.Marshal()
+	if err != nil {
+		return ""
+	}
+	return line
+}
+
+func escapeIrcTagValue(ircMessageTagValue string) string {
+	return ircTagValueEscaper.Replace(ircMessageTagValue)
+}
+
+func ParseIrcMessage(rawIrcMessage string) (*IrcMessage, error) {
+	// Lines may still have their line ending attached
+	rawIrcMessage = strings.TrimRight(rawIrcMessage, "\r\n")
+	// Ensure rawIrcMessage is not blank
+	if rawIrcMessage == "" {
+		return nil, ErrBlankRawIrcMessage
+	}
+	// Create parsed IrcMessage struct
+	parsedIrcMessage := &IrcMessage{
+		Raw: rawIrcMessage,
+	}
+	remaining := rawIrcMessage
+	// Check if the message starts with a tags section
+	if strings.HasPrefix(remaining, "@") {
+		var rawIrcMessageTags string
+		rawIrcMessageTags, remaining = nextIrcMessageSegment(remaining)
+		parsedIrcMessageTags, err := parseIrcMessageTags(rawIrcMessageTags)
+		if err != nil {
+			return nil, err
+		}
+		parsedIrcMessage.Tags = parsedIrcMessageTags
+	}
+	// Message source
+	if strings.HasPrefix(remaining, ":") {
+		var rawIrcMessageSource string
+		rawIrcMessageSource, remaining = nextIrcMessageSegment(remaining)
+		parsedIrcMessageSource, err := parseIrcMessageSource(rawIrcMessageSource)
+		if err != nil {
+			return nil, err
+		}
+		parsedIrcMessage.Source = parsedIrcMessageSource
+	}
+	// Message command
+	parsedIrcMessage.Command, remaining = nextIrcMessageSegment(remaining)
+	if parsedIrcMessage.Command == "" {
+		return nil, ErrBlankIrcCommand
+	}
+	// Remaining segments are params, a param starting with : is the trailing
+	// param and takes the rest of the line as is
+	for remaining != "" {
+		if strings.HasPrefix(remaining, ":") {
+			parsedIrcMessage.Params = append(parsedIrcMessage.Params, remaining[1:])
+			break
+		}
+		var param string
+		param, remaining = nextIrcMessageSegment(remaining)
+		parsedIrcMessage.Params = append(parsedIrcMessage.Params, param)
+	}
+	return parsedIrcMessage, nil
+}
+
+func nextIrcMessageSegment(rawIrcMessage string) (string, string) {
+	segment, remaining, _ := strings.Cut(rawIrcMessage, " ")
+	// Segments can be separated by more than one space
+	return segment, strings.TrimLeft(remaining, " ")
+}
+
+func parseIrcMessageSource(rawIrcMessageSource string) (*IrcMessageSource, error) {
+	rawIrcMessageSource = strings.TrimPrefix(rawIrcMessageSource, ":")
+	if rawIrcMessageSource == "" {
+		return nil, ErrBlankIrcMessageSource
+	}
+	parsedIrcMessageSource := &IrcMessageSource{}
+	// Source is either host or nickname[!username]@host
+	nicknameAndUsername, host, found := strings.Cut(rawIrcMessageSource, "@")
+	if !found {
+		// Server sources only have a host
+		if !strings.Contains(rawIrcMessageSource, "!") {
+			parsedIrcMessageSource.Host = rawIrcMessageSource
+			return parsedIrcMessageSource, nil
+		}
+		nicknameAndUsername = rawIrcMessageSource
+	}
+	parsedIrcMessageSource.Host = host
+	parsedIrcMessageSource.Nickname, parsedIrcMessageSource.Username, _ = strings.Cut(nicknameAndUsername, "!")
+	return parsedIrcMessageSource, nil
+}
+
+func parseIrcMessageTags(rawIrcMessageTags string) (map[string]string, error) {
+	rawIrcMessageTags = strings.TrimPrefix(rawIrcMessageTags, "@")
+	if rawIrcMessageTags == "" {
+		return nil, ErrBlankIrcMessageTags
+	}
+	parsedIrcMessageTags := make(map[string]string)
+	for _, rawIrcMessageTag := range strings.Split(rawIrcMessageTags, ";") {
+		// A tag without a value is the same as a tag with an empty value
+		rawIrcMessageTagKey, rawIrcMessageTagValue, _ := strings.Cut(rawIrcMessageTag, "=")
+		if rawIrcMessageTagKey == "" {
+			continue
+		}
+		parsedIrcMessageTags[rawIrcMessageTagKey] = unescapeIrcTagValue(rawIrcMessageTagValue)
+	}
+	return parsedIrcMessageTags, nil
+}
+
+func unescapeIrcTagValue(rawIrcMessageTagValue string) string {
+	// Nothing to do if there are no escape sequences
+	if !strings.Contains(rawIrcMessageTagValue, `\`) {
+		return rawIrcMessageTagValue
+	}
+	unescaped := &strings.Builder{}
+	for index := 0; index < len(rawIrcMessageTagValue); index++ {
+		character := rawIrcMessageTagValue[index]
+		if character != '\\' {
+			unescaped.WriteByte(character)
+			continue
+		}
+		// A trailing backslash on its own is dropped
+		index++
+		if index == len(rawIrcMessageTagValue) {
+			break
+		}
+		switch rawIrcMessageTagValue[index] {
+		case ':':
+			unescaped.WriteByte(';')
+		case 's':
+			unescaped.WriteByte(' ')
+		case 'r':
+			unescaped.WriteByte('\r')
+		case 'n':
+			unescaped.WriteByte('\n')
+		default:
+			// This includes \\ which becomes a single backslash
+			unescaped.WriteByte(rawIrcMessageTagValue[index])
+		}
+	}
+	return unescaped.String()
+}
@@ -0,0 +1,404 @@
+package twitch
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseIrcMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected *IrcMessage
+		err      error
+	}{
+		{
+			name: "command only",
+			raw:  "RECONNECT",
+			expected: &IrcMessage{
+				Command: "RECONNECT",
+			},
+		},
+		{
+			name: "line ending",
+			raw:  "PING :tmi.twitch.tv\r\n",
+			expected: &IrcMessage{
+				Command: "PING",
+				Params:  []string{"tmi.twitch.tv"},
+			},
+		},
+		{
+			name: "server source",
+			raw:  ":tmi.twitch.tv 001 testbot :Welcome, GLHF!",
+			expected: &IrcMessage{
+				Command: "001",
+				Params:  []string{"testbot", "Welcome, GLHF!"},
+				Source: &IrcMessageSource{
+					Host: "tmi.twitch.tv",
+				},
+			},
+		},
+		{
+			name: "user source",
+			raw:  ":viewer!viewer@viewer.tmi.twitch.tv JOIN #channel",
+			expected: &IrcMessage{
+				Command: "JOIN",
+				Params:  []string{"#channel"},
+				Source: &IrcMessageSource{
+					Nickname: "viewer",
+					Username: "viewer",
+					Host:     "viewer.tmi.twitch.tv",
+				},
+			},
+		},
+		{
+			name: "trailing param with runs of spaces",
+			raw:  ":viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :  hello   there  ",
+			expected: &IrcMessage{
+				Command: "PRIVMSG",
+				Params:  []string{"#channel", "  hello   there  "},
+				Source: &IrcMessageSource{
+					Nickname: "viewer",
+					Username: "viewer",
+					Host:     "viewer.tmi.twitch.tv",
+				},
+			},
+		},
+		{
+			name: "runs of spaces between segments",
+			raw:  "@id=1   :tmi.twitch.tv   NOTICE   #channel   :hello :world",
+			expected: &IrcMessage{
+				Command: "NOTICE",
+				Params:  []string{"#channel", "hello :world"},
+				Source: &IrcMessageSource{
+					Host: "tmi.twitch.tv",
+				},
+				Tags: map[string]string{
+					"id": "1",
+				},
+			},
+		},
+		{
+			name: "empty trailing param",
+			raw:  "PRIVMSG #channel :",
+			expected: &IrcMessage{
+				Command: "PRIVMSG",
+				Params:  []string{"#channel", ""},
+			},
+		},
+		{
+			name: "escaped tag values",
+			raw:  `@space=a\sb;semicolon=a\:b;backslash=a\\b;cr=a\rb;lf=a\nb;unknown=a\bc;trailing=ab\ USERSTATE #channel`,
+			expected: &IrcMessage{
+				Command: "USERSTATE",
+				Params:  []string{"#channel"},
+				Tags: map[string]string{
+					"space":     "a b",
+					"semicolon": "a;b",
+					"backslash": `a\b`,
+					"cr":        "a\rb",
+					"lf":        "a\nb",
+					"unknown":   "abc",
+					"trailing":  "ab",
+				},
+			},
+		},
+		{
+			name: "tags without values",
+			raw:  "@badge-info=;emote-only;color= PRIVMSG #channel :hi",
+			expected: &IrcMessage{
+				Command: "PRIVMSG",
+				Params:  []string{"#channel", "hi"},
+				Tags: map[string]string{
+					"badge-info": "",
+					"emote-only": "",
+					"color":      "",
+				},
+			},
+		},
+		{
+			name: "empty tags are skipped",
+			raw:  "@;id=1;; PING :tmi.twitch.tv",
+			expected: &IrcMessage{
+				Command: "PING",
+				Params:  []string{"tmi.twitch.tv"},
+				Tags: map[string]string{
+					"id": "1",
+				},
+			},
+		},
+		{
+			name: "blank message",
+			raw:  "",
+			err:  ErrBlankRawIrcMessage,
+		},
+		{
+			name: "line ending only",
+			raw:  "\r\n",
+			err:  ErrBlankRawIrcMessage,
+		},
+		{
+			name: "blank tags",
+			raw:  "@ PING :tmi.twitch.tv",
+			err:  ErrBlankIrcMessageTags,
+		},
+		{
+			name: "blank source",
+			raw:  ": PING :tmi.twitch.tv",
+			err:  ErrBlankIrcMessageSource,
+		},
+		{
+			name: "missing command after tags",
+			raw:  "@id=1",
+			err:  ErrBlankIrcCommand,
+		},
+		{
+			name: "missing command after source",
+			raw:  ":tmi.twitch.tv ",
+			err:  ErrBlankIrcCommand,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsed, err := ParseIrcMessage(test.raw)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+			if test.err != nil {
+				return
+			}
+			// Raw is always the line without its line ending
+			test.expected.Raw = parsed.Raw
+			if !reflect.DeepEqual(parsed, test.expected) {
+				t.Errorf("got %+v, want %+v", parsed, test.expected)
+			}
+		})
+	}
+}
+
+func TestIrcMessageRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  *IrcMessage
+		expected string
+	}{
+		{
+			name: "command only",
+			message: &IrcMessage{
+				Command: "QUIT",
+			},
+			expected: "QUIT",
+		},
+		{
+			name: "trailing param",
+			message: &IrcMessage{
+				Command: "PRIVMSG",
+				Params:  []string{"#channel", "hello  there"},
+			},
+			expected: "PRIVMSG #channel :hello  there",
+		},
+		{
+			name: "trailing param starting with a colon",
+			message: &IrcMessage{
+				Command: "PRIVMSG",
+				Params:  []string{"#channel", ":)"},
+			},
+			expected: "PRIVMSG #channel ::)",
+		},
+		{
+			name: "empty trailing param",
+			message: &IrcMessage{
+				Command: "PRIVMSG",
+				Params:  []string{"#channel", ""},
+			},
+			expected: "PRIVMSG #channel :",
+		},
+		{
+			name: "escaped tags are sorted",
+			message: &IrcMessage{
+				Command: "PRIVMSG",
+				Params:  []string{"#channel", "hi"},
+				Tags: map[string]string{
+					"reply-parent-msg-id": "a b;c\\d\r\n",
+					"client-nonce":        "1",
+				},
+			},
+			expected: `@client-nonce=1;reply-parent-msg-id=a\sb\:c\\d\r\n PRIVMSG #channel hi`,
+		},
+		{
+			name: "user source",
+			message: &IrcMessage{
+				Command: "JOIN",
+				Params:  []string{"#channel"},
+				Source: &IrcMessageSource{
+					Nickname: "viewer",
+					Username: "viewer",
+					Host:     "viewer.tmi.twitch.tv",
+				},
+			},
+			expected: ":viewer!viewer@viewer.tmi.twitch.tv JOIN #channel",
+		},
+		{
+			name: "server source",
+			message: &IrcMessage{
+				Command: "PONG",
+				Params:  []string{"tmi.twitch.tv"},
+				Source: &IrcMessageSource{
+					Host: "tmi.twitch.tv",
+				},
+			},
+			expected: ":tmi.twitch.tv PONG tmi.twitch.tv",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line, err := test.message.Marshal()
+			if err != nil {
+				t.Fatalf("unable to marshal: %s", err)
+			}
+			if line != test.expected {
+				t.Errorf("marshalled to %q, want %q", line, test.expected)
+			}
+			parsed, err := ParseIrcMessage(line)
+			if err != nil {
+				t.Fatalf("unable to parse %q: %s", line, err)
+			}
+			test.message.Raw = line
+			if !reflect.DeepEqual(parsed, test.message) {
+				t.Errorf("parsed to %+v, want %+v", parsed, test.message)
+			}
+		})
+	}
+}
+
+func TestIrcMessageMarshalErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		message *IrcMessage
+		err     error
+	}{
+		{
+			name:    "blank command",
+			message: &IrcMessage{},
+			err:     ErrBlankIrcCommand,
+		},
+		{
+			name: "command with a space",
+			message: &IrcMessage{
+				Command: "PRIVMSG #channel",
+			},
+			err: ErrInvalidIrcCommand,
+		},
+		{
+			name: "command with a tag prefix",
+			message: &IrcMessage{
+				Command: "@",
+			},
+			err: ErrInvalidIrcCommand,
+		},
+		{
+			name: "line break in the trailing param",
+			message: &IrcMessage{
+				Command: "PRIVMSG",
+				Params:  []string{"#channel", "hi\r\nQUIT"},
+			},
+			err: ErrInvalidIrcParam,
+		},
+		{
+			name: "space in a middle param",
+			message: &IrcMessage{
+				Command: "PRIVMSG",
+				Params:  []string{"#a b", "hi"},
+			},
+			err: ErrInvalidIrcParam,
+		},
+		{
+			name: "invalid tag key",
+			message: &IrcMessage{
+				Command: "PRIVMSG",
+				Tags: map[string]string{
+					"a=b": "c",
+				},
+			},
+			err: ErrInvalidIrcTagKey,
+		},
+		{
+			name: "blank source",
+			message: &IrcMessage{
+				Command: "PING",
+				Source:  &IrcMessageSource{},
+			},
+			err: ErrInvalidIrcMessageSource,
+		},
+		{
+			name: "host that reads back as a user",
+			message: &IrcMessage{
+				Command: "PING",
+				Source: &IrcMessageSource{
+					Host: "a!b",
+				},
+			},
+			err: ErrInvalidIrcMessageSource,
+		},
+		{
+			name: "username without a nickname",
+			message: &IrcMessage{
+				Command: "PING",
+				Source: &IrcMessageSource{
+					Username: "viewer",
+					Host:     "tmi.twitch.tv",
+				},
+			},
+			err: ErrInvalidIrcMessageSource,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.message.Marshal()
+			if !errors.Is(err, test.err) {
+				t.Errorf("got error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func FuzzParseIrcMessage(f *testing.F) {
+	seeds := []string{
+		"PING :tmi.twitch.tv",
+		":tmi.twitch.tv 001 testbot :Welcome, GLHF!",
+		":viewer!viewer@viewer.tmi.twitch.tv JOIN #channel",
+		`@badge-info=;badges=broadcaster/1;color=#0000FF;display-name=Viewer;emotes=;id=abc;mod=0;room-id=1;tmi-sent-ts=1;user-id=1 :viewer!viewer@viewer.tmi.twitch.tv PRIVMSG #channel :hello  there`,
+		`@msg-id=msg_duplicate :tmi.twitch.tv NOTICE #channel :Your message was not sent`,
+		`@a=\s\:\\\r\n\;b;c :host CMD a b :`,
+		"@ PING",
+		": PING",
+		"@;; :a!b@c X",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw string) {
+		parsed, err := ParseIrcMessage(raw)
+		if err != nil {
+			return
+		}
+		// Not everything that parses can be sent, but whatever can be sent
+		// must parse back to the same line
+		line, err := parsed.Marshal()
+		if err != nil {
+			return
+		}
+		reparsed, err := ParseIrcMessage(line)
+		if err != nil {
+			t.Fatalf("unable to parse marshalled %q: %s", line, err)
+		}
+		relined, err := reparsed.Marshal()
+		if err != nil {
+			t.Fatalf("unable to marshal reparsed %q: %s", line, err)
+		}
+		if relined != line {
+			t.Fatalf("marshalled %q, then %q", line, relined)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package twitchtest
+
+type AuthProvider struct {
+	AccessToken string
+	Login       string
+}
+
+func (a *AuthProvider) GetAccessToken() (string, error) {
+	return a.AccessToken, nil
+}
+
+func (a *AuthProvider) GetLoginAndAccessToken() (string, string, error) {
+	return a.Login, a.AccessToken, nil
+}
+
+func NewAuthProvider(login string) *AuthProvider {
+	return &AuthProvider{
+		AccessToken: "twitchtest",
+		Login:       login,
+	}
+}
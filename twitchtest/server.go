@@ -0,0 +1,289 @@
+package twitchtest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ynotnauk/go-twitch"
+)
+
+const (
+	serverHost string = "tmi.twitch.tv"
+)
+
+var (
+	ErrServerClosed error = errors.New("server has been closed")
+	ErrTimeout      error = errors.New("timed out waiting for the client")
+)
+
+type Server struct {
+	address     string
+	changed     chan bool
+	closed      bool
+	connections map[io.ReadWriteCloser]string
+	consumed    map[int]bool
+	handshakes  int
+	listener    net.Listener
+	messageIds  int
+	mutex       sync.Mutex
+	received    []string
+	rejection   string
+	websocket   bool
+}
+
+func (s *Server) Address() string {
+	return s.address
+}
+
+func (s *Server) ClientOptions() []twitch.ChatClientOption {
+	options := []twitch.ChatClientOption{
+		twitch.WithAddress(s.address),
+		twitch.WithPlaintext(),
+	}
+	if s.websocket {
+		options = append(options, twitch.WithWebSocket())
+	}
+	return options
+}
+
+func (s *Server) Close() error {
+	s.mutex.Lock()
+	s.closed = true
+	s.notify()
+	s.mutex.Unlock()
+	err := s.listener.Close()
+	s.Disconnect()
+	return err
+}
+
+func (s *Server) Disconnect() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for connection := range s.connections {
+		connection.Close()
+	}
+}
+
+func (s *Server) handleConnection(rawConnection net.Conn) {
+	// Upgrade to a websocket first when serving over one
+	var connection io.ReadWriteCloser = rawConnection
+	if s.websocket {
+		websocketConnection, err := acceptWebSocket(rawConnection)
+		if err != nil {
+			log.Printf("twitchtest: failed to accept websocket: %s", err)
+			rawConnection.Close()
+			return
+		}
+		connection = websocketConnection
+	}
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		connection.Close()
+		return
+	}
+	s.connections[connection] = ""
+	s.notify()
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.connections, connection)
+		s.notify()
+		s.mutex.Unlock()
+		connection.Close()
+	}()
+	tp := textproto.NewReader(bufio.NewReader(connection))
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		// Record the line so tests can assert on it
+		s.mutex.Lock()
+		s.received = append(s.received, line)
+		s.notify()
+		login := s.connections[connection]
+		s.mutex.Unlock()
+		// Respond the same way Twitch would, tags aren't needed for that
+		untagged := line
+		if strings.HasPrefix(untagged, "@") {
+			_, untagged, _ = strings.Cut(untagged, " ")
+		}
+		command, params, _ := strings.Cut(untagged, " ")
+		switch command {
+		case "CAP":
+			capabilities := params
+			if _, after, found := strings.Cut(params, ":"); found {
+				capabilities = after
+			}
+			s.write(connection, fmt.Sprintf(":%s CAP * ACK :%s", serverHost, capabilities))
+		case "NICK":
+			login = strings.ToLower(params)
+			s.mutex.Lock()
+			s.connections[connection] = login
+			s.mutex.Unlock()
+			// Both lines are written together, which over a websocket puts
+			// them in a single frame like Twitch does
+			s.write(connection, fmt.Sprintf(":%s 001 %s :Welcome, GLHF!\r\n:%s 376 %s :>", serverHost, login, serverHost, login))
+			s.mutex.Lock()
+			s.handshakes++
+			s.notify()
+			s.mutex.Unlock()
+		case "JOIN", "PART":
+			for _, channel := range strings.Split(params, ",") {
+				s.write(connection, fmt.Sprintf(":%s!%s@%s.%s %s %s", login, login, login, serverHost, command, channel))
+			}
+		case "PRIVMSG":
+			channel, _, _ := strings.Cut(params, " ")
+			s.mutex.Lock()
+			s.messageIds++
+			messageId := s.messageIds
+			rejection := s.rejection
+			s.mutex.Unlock()
+			// Twitch confirms every message with a USERSTATE carrying its id
+			if rejection != "" {
+				s.write(connection, fmt.Sprintf("@msg-id=%s :%s NOTICE %s :Your message was not sent.", rejection, serverHost, channel))
+			} else {
+				s.write(connection, fmt.Sprintf("@badge-info=;badges=;color=;display-name=%s;emote-sets=0;id=message-%d;mod=0;subscriber=0;user-type= :%s USERSTATE %s", login, messageId, serverHost, channel))
+			}
+		case "PING":
+			s.write(connection, fmt.Sprintf(":%s PONG %s %s", serverHost, serverHost, params))
+		case "QUIT":
+			return
+		}
+	}
+}
+
+func (s *Server) Handshakes() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.handshakes
+}
+
+func (s *Server) notify() {
+	// Wake up anything waiting for a change
+	close(s.changed)
+	s.changed = make(chan bool)
+}
+
+func (s *Server) Received() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	received := make([]string, len(s.received))
+	copy(received, s.received)
+	return received
+}
+
+func (s *Server) RejectMessages(noticeType string) {
+	// Messages are answered with a NOTICE of this type instead of being
+	// confirmed, a blank type goes back to confirming them
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.rejection = noticeType
+}
+
+func (s *Server) Send(rawIrcMessage string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for connection := range s.connections {
+		s.write(connection, rawIrcMessage)
+	}
+}
+
+func (s *Server) start() {
+	go func() {
+		for {
+			connection, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handleConnection(connection)
+		}
+	}()
+}
+
+func (s *Server) wait(timeout time.Duration, condition func() bool) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		s.mutex.Lock()
+		if condition() {
+			s.mutex.Unlock()
+			return nil
+		}
+		if s.closed {
+			s.mutex.Unlock()
+			return ErrServerClosed
+		}
+		changed := s.changed
+		s.mutex.Unlock()
+		select {
+		case <-changed:
+		case <-timer.C:
+			return ErrTimeout
+		}
+	}
+}
+
+func (s *Server) WaitForHandshakes(count int, timeout time.Duration) error {
+	return s.wait(timeout, func() bool {
+		return s.handshakes >= count
+	})
+}
+
+func (s *Server) WaitForLine(prefix string, timeout time.Duration) (string, error) {
+	line := ""
+	err := s.wait(timeout, func() bool {
+		// Find the first line with the prefix that has not already been returned
+		for index, received := range s.received {
+			if !s.consumed[index] && strings.HasPrefix(received, prefix) {
+				s.consumed[index] = true
+				line = received
+				return true
+			}
+		}
+		return false
+	})
+	return line, err
+}
+
+func (s *Server) write(connection io.Writer, rawIrcMessage string) {
+	_, err := connection.Write([]byte(rawIrcMessage + "\r\n"))
+	if err != nil {
+		log.Printf("twitchtest: failed to write to client: %s", err)
+	}
+}
+
+func newServer(websocket bool) (*Server, error) {
+	// Listen on a random local port
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	server := &Server{
+		address:     listener.Addr().String(),
+		changed:     make(chan bool),
+		connections: make(map[io.ReadWriteCloser]string),
+		consumed:    make(map[int]bool),
+		listener:    listener,
+		websocket:   websocket,
+	}
+	server.start()
+	return server, nil
+}
+
+func NewServer() (*Server, error) {
+	return newServer(false)
+}
+
+func NewWebSocketServer() (*Server, error) {
+	return newServer(true)
+}
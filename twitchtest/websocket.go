@@ -0,0 +1,180 @@
+package twitchtest
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	websocketAcceptGuid string = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+var (
+	ErrInvalidWebSocketFrame     error = errors.New("invalid websocket frame")
+	ErrInvalidWebSocketHandshake error = errors.New("invalid websocket handshake")
+)
+
+type websocketConnection struct {
+	closeOnce      sync.Once
+	connection     net.Conn
+	frameMask      []byte
+	frameOffset    int
+	frameRemaining uint64
+	reader         *bufio.Reader
+	writeMutex     sync.Mutex
+}
+
+func (w *websocketConnection) Close() error {
+	w.closeOnce.Do(func() {
+		w.connection.SetWriteDeadline(time.Now().Add(time.Second))
+		w.writeFrame(0x8, nil)
+	})
+	return w.connection.Close()
+}
+
+func (w *websocketConnection) Read(p []byte) (int, error) {
+	for w.frameRemaining == 0 {
+		err := w.readFrameHeader()
+		if err != nil {
+			return 0, err
+		}
+	}
+	size := min(uint64(len(p)), w.frameRemaining)
+	n, err := w.reader.Read(p[:size])
+	for i := range n {
+		p[i] ^= w.frameMask[(w.frameOffset+i)%4]
+	}
+	w.frameOffset += n
+	w.frameRemaining -= uint64(n)
+	return n, err
+}
+
+func (w *websocketConnection) readFrameHeader() error {
+	header := make([]byte, 2)
+	_, err := io.ReadFull(w.reader, header)
+	if err != nil {
+		return err
+	}
+	opcode := header[0] & 0x0F
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		_, err = io.ReadFull(w.reader, extended)
+		if err != nil {
+			return err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		_, err = io.ReadFull(w.reader, extended)
+		if err != nil {
+			return err
+		}
+		length = binary.BigEndian.Uint64(extended)
+	}
+	// Clients must always mask their frames
+	if header[1]&0x80 == 0 {
+		return ErrInvalidWebSocketFrame
+	}
+	w.frameMask = make([]byte, 4)
+	_, err = io.ReadFull(w.reader, w.frameMask)
+	if err != nil {
+		return err
+	}
+	w.frameOffset = 0
+	switch opcode {
+	case 0x0, 0x1, 0x2:
+		w.frameRemaining = length
+		return nil
+	case 0x8, 0x9, 0xA:
+		payload := make([]byte, length)
+		_, err = io.ReadFull(w.reader, payload)
+		if err != nil {
+			return err
+		}
+		for i := range payload {
+			payload[i] ^= w.frameMask[i%4]
+		}
+		switch opcode {
+		case 0x8:
+			w.closeOnce.Do(func() {
+				w.writeFrame(0x8, payload)
+			})
+			return io.EOF
+		case 0x9:
+			return w.writeFrame(0xA, payload)
+		}
+		return nil
+	default:
+		return ErrInvalidWebSocketFrame
+	}
+}
+
+func (w *websocketConnection) Write(p []byte) (int, error) {
+	// Everything in a single write goes out as one text frame, the same way
+	// Twitch batches several lines together
+	err := w.writeFrame(0x1, p)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *websocketConnection) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	length := len(payload)
+	switch {
+	case length <= 125:
+		frame = append(frame, byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+	frame = append(frame, payload...)
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	_, err := w.connection.Write(frame)
+	return err
+}
+
+func acceptWebSocket(connection net.Conn) (*websocketConnection, error) {
+	reader := bufio.NewReader(connection)
+	request, err := http.ReadRequest(reader)
+	if err != nil {
+		return nil, err
+	}
+	key := request.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(request.Header.Get("Upgrade"), "websocket") ||
+		request.Header.Get("Sec-WebSocket-Version") != "13" ||
+		key == "" {
+		connection.Write([]byte("HTTP/1.1 400 Bad Request\r\n\r\n"))
+		return nil, ErrInvalidWebSocketHandshake
+	}
+	hash := sha1.Sum([]byte(key + websocketAcceptGuid))
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n\r\n"
+	_, err = connection.Write([]byte(response))
+	if err != nil {
+		return nil, err
+	}
+	transport := &websocketConnection{
+		connection: connection,
+		reader:     reader,
+	}
+	return transport, nil
+}